@@ -0,0 +1,193 @@
+/*
+This file contains the functions/methods for resolving the name of the owner of a bank account
+
+Nigerian account charges and NGN transfers with "expected_account_name"
+(ExpectedAccountName in typed requests) are rejected with ErrAccountNameMismatch
+before they're sent if the account isn't owned by that name, the name of other
+accounts can't be resolved so they're rejected with ErrAccountNameUnchecked. Clients created
+with WithAccountNameCheck(true) check every charge against the "firstname" and
+"lastname" of the customer and every transfer against "beneficiary_name".
+*/
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrAccountNotResolved : Returned when Rave can't find the owner of a bank account
+var ErrAccountNotResolved = errors.New("Account could not be resolved")
+
+// ErrAccountNameMismatch : Returned when the name sent with a charge or transfer isn't the name of the account
+var ErrAccountNameMismatch = errors.New("Account name doesn't match")
+
+// ErrAccountNameUnchecked : Returned when a charge or transfer has an expected account name but the account can't be resolved
+var ErrAccountNameUnchecked = errors.New("The account name can't be checked")
+
+// WithAccountNameCheck : Resolve accounts before they're charged or paid out to and reject name mismatches
+func WithAccountNameCheck(enabled bool) Option {
+	return func(r *Rave) {
+		r.checkAccountNames = enabled
+	}
+}
+
+// parameters required to resolve an account
+var resolveAccountParameters = []string{"recipientaccount", "destbankcode"}
+
+// ResolveAccount : Get the name of the owner of a bank account, ErrAccountNotResolved is returned if it doesn't exist
+func (r Rave) ResolveAccount(ctx context.Context, accountNumber, bankCode string) (*ResolvedAccount, error) {
+	if accountNumber == "" {
+		return nil, &ParameterError{Parameter: "accountNumber", Method: "ResolveAccount"}
+	}
+
+	if bankCode == "" {
+		return nil, &ParameterError{Parameter: "bankCode", Method: "ResolveAccount"}
+	}
+
+	response, err := r.ResolveAccountTyped(ctx, ResolveAccountRequest{AccountNumber: accountNumber, BankCode: bankCode})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Data.Data, nil
+}
+
+// ResolveAccountContext : Same as ResolveAccount but with the parameters Rave expects
+// ("recipientaccount" and "destbankcode") in a map
+func (r Rave) ResolveAccountContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	err := checkRequiredParameters("ResolveAccountContext", data, resolveAccountParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.resolveAccount(ctx, data)
+}
+
+// ResolveAccountTyped : Typed version of ResolveAccountContext, ErrAccountNotResolved is returned if the account doesn't exist
+func (r Rave) ResolveAccountTyped(ctx context.Context, request ResolveAccountRequest) (*ResolveAccountResponse, error) {
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRequiredParameters("ResolveAccountTyped", data, resolveAccountParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.resolveAccount(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	resolveResponse := &ResolveAccountResponse{}
+	err = decodeResponse(response, resolveResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	account := resolveResponse.Data.Data
+	if account.ResponseCode != "00" || account.AccountName == "" {
+		return nil, fmt.Errorf(
+			"%w: \"%s\" at bank \"%s\" (%s)", ErrAccountNotResolved, request.AccountNumber, request.BankCode,
+			account.ResponseMessage,
+		)
+	}
+
+	return resolveResponse, nil
+}
+
+// resolveAccount : Sends an account resolution request to Rave
+func (r Rave) resolveAccount(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	publicKey, err := r.getPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	data["PBFPubKey"] = publicKey
+	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/resolve_account"
+
+	return r.withRetries(ctx, nil, func() ([]byte, error) {
+		return r.makePostRequest(ctx, URL, data)
+	})
+}
+
+// MatchesName : Reports whether every word of names is part of the account name.
+// Case, punctuation and the order of the words are ignored, banks often put the last name first.
+func (a ResolvedAccount) MatchesName(names ...string) bool {
+	accountWords := map[string]bool{}
+	for _, word := range nameWords(a.AccountName) {
+		accountWords[word] = true
+	}
+
+	words := nameWords(strings.Join(names, " "))
+	for _, word := range words {
+		if !accountWords[word] {
+			return false
+		}
+	}
+
+	return len(words) > 0
+}
+
+// nameWords : Split a name into upper case words without punctuation
+func nameWords(name string) []string {
+	return strings.FieldsFunc(strings.ToUpper(name), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+}
+
+// checkAccountName : Resolve the account of a charge or transfer and make sure it belongs to names.
+// "expected_account_name" is removed from data, when it's set the account is always resolved and
+// only compared with it. Otherwise the account is only resolved if the client checks account names.
+func (r Rave) checkAccountName(
+	ctx context.Context, data map[string]interface{}, accountKey, bankKey string, names ...string,
+) error {
+	expected, _ := data["expected_account_name"].(string)
+	delete(data, "expected_account_name")
+
+	if expected != "" {
+		names = []string{expected}
+	} else if !r.checkAccountNames {
+		return nil
+	}
+
+	accountNumber, bankCode := data[accountKey], data[bankKey]
+	if accountNumber == nil || bankCode == nil {
+		if expected != "" {
+			return fmt.Errorf("%w: \"%s\" and \"%s\" are required", ErrAccountNameUnchecked, accountKey, bankKey)
+		}
+
+		return nil
+	}
+
+	account, err := r.ResolveAccount(ctx, fmt.Sprint(accountNumber), fmt.Sprint(bankCode))
+	if err != nil {
+		return err
+	}
+
+	if len(nameWords(strings.Join(names, " "))) > 0 && !account.MatchesName(names...) {
+		return fmt.Errorf(
+			"%w: \"%s\" is owned by \"%s\"", ErrAccountNameMismatch, strings.Join(names, " "), account.AccountName,
+		)
+	}
+
+	return nil
+}
+
+// skipAccountName : Remove "expected_account_name" from a charge or transfer whose account
+// isn't resolved, the caller is told with ErrAccountNameUnchecked if it was set
+func skipAccountName(data map[string]interface{}, reason string) error {
+	expected, _ := data["expected_account_name"].(string)
+	delete(data, "expected_account_name")
+
+	if expected != "" {
+		return fmt.Errorf("%w: %s", ErrAccountNameUnchecked, reason)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// Tests for account name resolution
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// Names should match regardless of case, punctuation and the order of the words
+func TestResolvedAccountMatchesName(t *testing.T) {
+	t.Parallel()
+
+	account := ResolvedAccount{AccountName: "ADEBAYO, TEMITOPE O."}
+
+	for names, matches := range map[[2]string]bool{
+		{"Temitope", "Adebayo"}: true,
+		{"adebayo", ""}:         true,
+		{"Temitope O", ""}:      true,
+		{"Temi", "Adebayo"}:     false,
+		{"John", "Adebayo"}:     false,
+		{"", ""}:                false,
+	} {
+		assertEqual(t, account.MatchesName(names[0], names[1]), matches)
+	}
+}
+
+// Clients that check account names should reject charges and transfers before they're sent
+func TestAccountNameCheck(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	server.SetAccountName("0690000031", "ADEBAYO TEMI")
+	server.SetAccountName("0690000040", "OKAFOR CHIOMA")
+
+	client := NewClient(
+		WithKeys(server.PublicKey, server.SecretKey),
+		WithBaseURLs("", server.URL),
+		WithHTTPClient(server.Client()),
+		WithRetryPolicy(NoRetries),
+		WithAccountNameCheck(true),
+	)
+	ctx := context.Background()
+
+	charge := AccountChargeRequest{
+		Customer: customer, AccountNumber: "0690000031", AccountBank: "044", Amount: MustMoney("1000", "NGN"),
+		TxRef: "resolved-1", PaymentType: "account",
+	}
+	if _, err := client.ChargeAccountTyped(ctx, charge); err != nil {
+		t.Fatal(err)
+	}
+
+	charge.AccountNumber, charge.TxRef = "0690000040", "resolved-2"
+	_, err := client.ChargeAccountTyped(ctx, charge)
+	if !errors.Is(err, ErrAccountNameMismatch) || !IsValidationError(err) {
+		t.Errorf("Expected ErrAccountNameMismatch got %v", err)
+	}
+
+	charge.AccountNumber, charge.TxRef = ravetest.UnresolvableAccount, "resolved-3"
+	_, err = client.ChargeAccountTyped(ctx, charge)
+	if !errors.Is(err, ErrAccountNotResolved) {
+		t.Errorf("Expected ErrAccountNotResolved got %v", err)
+	}
+	assertEqual(t, server.Requests(ravetest.ChargePath), 1)
+
+	request := transferRequest("payout-resolved")
+	request.BeneficiaryName = "Temi Adebayo"
+	if _, err := client.InitiateTransferTyped(ctx, request); !errors.Is(err, ErrAccountNameMismatch) {
+		t.Errorf("Expected ErrAccountNameMismatch got %v", err)
+	}
+
+	request.BeneficiaryName = "Chioma Okafor"
+	if _, err := client.InitiateTransferTyped(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, server.Requests(ravetest.ResolveAccountPath), 5)
+
+	// accounts aren't resolved unless the client checks names
+	charge.AccountNumber, charge.TxRef = "0690000040", "resolved-4"
+	if _, err := newSessionClient(server).ChargeAccountTyped(ctx, charge); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, server.Requests(ravetest.ResolveAccountPath), 5)
+
+	_, err = client.ResolveAccount(ctx, "", "044")
+	assertEqual(t, err.Error(), "\"accountNumber\" is a required parameter for \"ResolveAccount\"")
+}
+
+// Charges and transfers with an expected account name should be checked by any client
+func TestExpectedAccountName(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	server.SetAccountName("0690000031", "ADEBAYO TEMI")
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	request := AccountChargeRequest{
+		Customer: customer, AccountNumber: "0690000031", AccountBank: "044", Amount: MustMoney("1000", "NGN"),
+		TxRef: "expected-1", PaymentType: "account", ExpectedAccountName: "Temi Okafor",
+	}
+	if _, err := client.ChargeAccountTyped(ctx, request); !errors.Is(err, ErrAccountNameMismatch) {
+		t.Errorf("Expected ErrAccountNameMismatch got %v", err)
+	}
+	assertEqual(t, server.Requests(ravetest.ChargePath), 0)
+
+	request.ExpectedAccountName = "Adebayo Temi"
+	if _, err := client.ChargeAccountTyped(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+
+	transfer := transferRequest("payout-expected")
+	transfer.ExpectedAccountName = "Chioma Okafor"
+	if _, err := client.InitiateTransferTyped(ctx, transfer); !errors.Is(err, ErrAccountNameMismatch) {
+		t.Errorf("Expected ErrAccountNameMismatch got %v", err)
+	}
+
+	transfer.ExpectedAccountName = ravetest.AccountName
+	if _, err := client.InitiateTransferTyped(ctx, transfer); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, server.Requests(ravetest.ResolveAccountPath), 4)
+
+	// accounts that can't be resolved are rejected instead of sent without the check
+	transfer.Amount = MustMoney("100", "USD")
+	if _, err := client.InitiateTransferTyped(ctx, transfer); !errors.Is(err, ErrAccountNameUnchecked) {
+		t.Errorf("Expected ErrAccountNameUnchecked got %v", err)
+	}
+
+	_, err := client.ChargeAccount(map[string]interface{}{
+		"accountnumber": "0000123456", "routingnumber": "021000021", "country": "US", "amount": "25.50",
+		"email": "user@example.com", "phonenumber": "0902620185", "firstname": "Temi", "lastname": "Adebayo",
+		"IP": "127.0.0.1", "txRef": "expected-us", "redirect_url": "https://example.com/callback",
+		"expected_account_name": "Temi Adebayo",
+	})
+	if !errors.Is(err, ErrAccountNameUnchecked) || !IsValidationError(err) {
+		t.Errorf("Expected ErrAccountNameUnchecked got %v", err)
+	}
+	assertEqual(t, server.Requests(ravetest.TransferPath), 1)
+	assertEqual(t, server.Requests(ravetest.ChargePath), 1)
+
+	resolved, err := client.ResolveAccountTyped(ctx, ResolveAccountRequest{AccountNumber: "0690000031", BankCode: "044"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, resolved.Data.Data.AccountName, "ADEBAYO TEMI")
+}
@@ -0,0 +1,108 @@
+// Tests for US (ACH) and South African account charges
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// US account charges should be sent with their flag and authorized at the auth URL
+func TestChargeUSAccount(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	response, err := client.ChargeUSAccountTyped(context.Background(), USAccountChargeRequest{
+		Customer: customer, AccountNumber: "0000123456", RoutingNumber: "021000021",
+		Amount: MustMoney("25.50", "USD"), TxRef: "ach-1", RedirectURL: "https://example.com/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, response.Data.AuthFlow(), AuthFlowRedirect)
+	assertEqual(t, response.Data.AuthURL, server.AuthURL(response.Data.FlwRef))
+	assertEqual(t, response.Data.Amount, MustMoney("25.50", "USD"))
+
+	payload := server.Charges()[0]
+	assertEqual(t, payload["country"], "US")
+	assertEqual(t, payload["payment_type"], "account")
+	assertEqual(t, fmt.Sprint(payload["is_us_bank_charge"]), "1")
+}
+
+// South African account charges need a passcode but no BVN and are validated with an OTP
+func TestChargeSouthAfricanAccount(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	request := SouthAfricanAccountChargeRequest{
+		Customer: customer, AccountNumber: "0000123456", AccountBank: "093",
+		Amount: MustMoney("150", "ZAR"), TxRef: "za-1",
+	}
+
+	_, err := client.ChargeSouthAfricanAccountTyped(context.Background(), request)
+	assertEqual(t, err.Error(), "\"passcode\" is a required parameter for \"ChargeSouthAfricanAccountTyped\"")
+
+	request.Passcode = "12345678"
+	response, err := client.ChargeSouthAfricanAccountTyped(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, response.Data.AuthFlow(), AuthFlowOTP)
+
+	payload := server.Charges()[0]
+	assertEqual(t, payload["country"], "ZA")
+	assertEqual(t, payload["currency"], "ZAR")
+	assertEqual(t, fmt.Sprint(payload["is_south_african_bank_account"]), "1")
+}
+
+// ChargeAccount should check the fields of the country the charge is made in
+func TestChargeAccountCountryParameters(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	data := map[string]interface{}{
+		"accountnumber": "0000123456", "country": "us", "amount": "25", "email": "user@example.com",
+		"phonenumber": "0902620185", "firstname": "Temi", "lastname": "Adebayo", "IP": "127.0.0.1", "txRef": "ach-2",
+		"redirect_url": "https://example.com/callback",
+	}
+
+	_, err := client.ChargeAccount(data)
+	assertEqual(t, err.Error(), "\"routingnumber\" is a required parameter for \"ChargeAccount\"")
+
+	data["routingnumber"] = "021000022"
+	_, err = client.ChargeAccount(data)
+	if !errors.Is(err, ErrInvalidRoutingNumber) || !IsValidationError(err) {
+		t.Errorf("Expected ErrInvalidRoutingNumber got %v", err)
+	}
+
+	data["routingnumber"] = "021000021"
+	data["currency"] = "NGN"
+	_, err = client.ChargeAccount(data)
+	if !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency got %v", err)
+	}
+
+	assertEqual(t, server.Requests(ravetest.ChargePath), 0)
+
+	delete(data, "currency")
+	if _, err := client.ChargeAccount(data); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, server.Charges()[0]["currency"], "USD")
+}
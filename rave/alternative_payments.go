@@ -0,0 +1,181 @@
+/* This file contains the functions/methods for USSD and Mcash charges (Alternative payment methods) */
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/antonholmquist/jason"
+)
+
+// parameters required by every USSD charge, accountbank is the code of the customer's bank
+var ussdChargeParameters = []string{
+	"accountbank", "amount", "email", "phonenumber", "firstname", "lastname", "IP", "txRef", "orderRef",
+}
+
+// parameters required by every Mcash charge
+var mcashChargeParameters = []string{
+	"amount", "email", "phonenumber", "firstname", "lastname", "IP", "txRef", "orderRef",
+}
+
+// ussdMethod : Payload of USSD charges
+var ussdMethod = paymentMethod{
+	name: "USSD", paymentType: "ussd", country: "NG", currencies: []string{"NGN"}, flags: []string{"is_ussd"},
+}
+
+// mcashMethod : Payload of Mcash charges
+var mcashMethod = paymentMethod{
+	name: "Mcash", paymentType: "mcash", country: "NG", currencies: []string{"NGN"}, flags: []string{"is_mcash"},
+}
+
+// ErrNoDialString : Returned by USSDDialString when the response doesn't contain a USSD string
+var ErrNoDialString = errors.New("The response doesn't contain a USSD string")
+
+// ErrNoPaymentCode : Returned by McashPaymentCode when the response doesn't contain a payment code
+var ErrNoPaymentCode = errors.New("The response doesn't contain a payment code")
+
+// dialStringPattern : USSD string in the instructions returned by Rave e.g "*737*50*1000*123#"
+var dialStringPattern = regexp.MustCompile(`\*[0-9*]+#`)
+
+// USSDDialString : Extract the USSD string the customer must dial from a ChargeUSSD response
+func USSDDialString(response []byte) (string, error) {
+	responseData, err := jason.NewObjectFromBytes(response)
+	if err != nil {
+		return "", err
+	}
+
+	// the string is either sent on it's own or inside the instructions
+	for _, field := range []string{"note", "validateInstructions", "chargeResponseMessage"} {
+		value, _ := responseData.GetString("data", field)
+		if dialString := dialStringPattern.FindString(value); dialString != "" {
+			return dialString, nil
+		}
+	}
+
+	return "", ErrNoDialString
+}
+
+// McashPaymentCode : Extract the code the customer pays with from a ChargeMcash response
+func McashPaymentCode(response []byte) (string, error) {
+	responseData, err := jason.NewObjectFromBytes(response)
+	if err != nil {
+		return "", err
+	}
+
+	for _, field := range []string{"paymentCode", "payment_code"} {
+		code, err := responseData.GetString("data", field)
+		if err == nil && code != "" {
+			return code, nil
+		}
+	}
+
+	return "", ErrNoPaymentCode
+}
+
+// ChargeUSSD : Charge a customer's bank account through USSD, payment_type and is_ussd are set for you.
+// The charge is pending until the customer dials the string returned by USSDDialString,
+// use VerifyTransaction to confirm it.
+func (r Rave) ChargeUSSD(data map[string]interface{}) ([]byte, error) {
+	return r.ChargeUSSDContext(context.Background(), data)
+}
+
+// ChargeUSSDContext : Same as ChargeUSSD but honors the cancellation and deadline of ctx
+func (r Rave) ChargeUSSDContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	chargeData, err := methodCharge("ChargeUSSD", ussdMethod, ussdChargeParameters, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.chargeMethod(ctx, chargeData)
+}
+
+// ChargeUSSDTyped : Typed version of ChargeUSSD, the response is returned with ErrNoDialString
+// when Rave doesn't send the USSD string
+func (r Rave) ChargeUSSDTyped(ctx context.Context, request USSDChargeRequest) (*USSDChargeResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+	request.Currency = currency
+
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeData, err := methodCharge("ChargeUSSDTyped", ussdMethod, ussdChargeParameters, data)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.chargeMethod(ctx, chargeData)
+	if err != nil {
+		return nil, err
+	}
+
+	ussdResponse := &USSDChargeResponse{}
+	err = decodeResponse(response, &ussdResponse.ChargeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	// the charge exists even without a dial string, keep it so it can be verified
+	ussdResponse.DialString, err = USSDDialString(response)
+
+	return ussdResponse, err
+}
+
+// ChargeMcash : Charge a customer with Mcash, payment_type and is_mcash are set for you.
+// The charge is pending until the customer pays with the code returned by McashPaymentCode,
+// use VerifyTransaction to confirm it.
+func (r Rave) ChargeMcash(data map[string]interface{}) ([]byte, error) {
+	return r.ChargeMcashContext(context.Background(), data)
+}
+
+// ChargeMcashContext : Same as ChargeMcash but honors the cancellation and deadline of ctx
+func (r Rave) ChargeMcashContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	chargeData, err := methodCharge("ChargeMcash", mcashMethod, mcashChargeParameters, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.chargeMethod(ctx, chargeData)
+}
+
+// ChargeMcashTyped : Typed version of ChargeMcash, the response is returned with ErrNoPaymentCode
+// when Rave doesn't send the payment code
+func (r Rave) ChargeMcashTyped(ctx context.Context, request McashChargeRequest) (*McashChargeResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+	request.Currency = currency
+
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeData, err := methodCharge("ChargeMcashTyped", mcashMethod, mcashChargeParameters, data)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.chargeMethod(ctx, chargeData)
+	if err != nil {
+		return nil, err
+	}
+
+	mcashResponse := &McashChargeResponse{}
+	err = decodeResponse(response, &mcashResponse.ChargeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	// the charge exists even without a payment code, keep it so it can be verified
+	mcashResponse.PaymentCode, err = McashPaymentCode(response)
+
+	return mcashResponse, err
+}
@@ -0,0 +1,156 @@
+// Tests for USSD and Mcash charges
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// ChargeUSSDTyped should return the string the customer dials and complete once they do
+func TestChargeUSSD(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	response, err := client.ChargeUSSDTyped(ctx, USSDChargeRequest{
+		Customer: customer, AccountBank: "058", Amount: MustMoney("1000", "NGN"), TxRef: "ussd-1", OrderRef: "order-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, response.DialString, fmt.Sprintf("*737*50*1000*%d#", response.Data.ID))
+	assertEqual(t, response.Data.Pending(), true)
+
+	payload := server.Charges()[0]
+	assertEqual(t, payload["payment_type"], "ussd")
+	assertEqual(t, fmt.Sprint(payload["is_ussd"]), "1")
+	assertEqual(t, payload["currency"], "NGN")
+
+	verifyRequest := VerifyRequest{FlwRef: response.Data.FlwRef, Amount: MustMoney("1000", "NGN"), Currency: "NGN"}
+	if _, err := client.VerifyTransactionTyped(ctx, verifyRequest); err == nil {
+		t.Fatal("Expected a pending USSD charge to fail verification")
+	}
+
+	assertEqual(t, server.ApprovePayment(response.Data.FlwRef), true)
+	if _, err := client.VerifyTransactionTyped(ctx, verifyRequest); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ChargeMcash should return a response the payment code can be extracted from
+func TestChargeMcash(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	response, err := client.ChargeMcash(map[string]interface{}{
+		"amount": "500", "email": "user@example.com", "phonenumber": "0902620185", "firstname": "Temi",
+		"lastname": "Adebayo", "IP": "127.0.0.1", "txRef": "mcash-1", "orderRef": "order-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paymentCode, err := McashPaymentCode(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chargeResponse := &ChargeResponse{}
+	if err := decodeResponse(response, chargeResponse); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, paymentCode, fmt.Sprintf("%06d", chargeResponse.Data.ID))
+
+	typedResponse, err := client.ChargeMcashTyped(context.Background(), McashChargeRequest{
+		Customer: customer, Amount: MustMoney("500", "NGN"), TxRef: "mcash-2", OrderRef: "order-2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, typedResponse.PaymentCode, fmt.Sprintf("%06d", typedResponse.Data.ID))
+	assertEqual(t, fmt.Sprint(server.Charges()[1]["is_mcash"]), "1")
+
+	_, err = USSDDialString(response)
+	assertEqual(t, err, ErrNoDialString)
+}
+
+// The pending charge should still be returned when Rave doesn't send the dial string or payment code
+func TestAlternativePaymentWithoutCode(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	pending := ravetest.Response{
+		StatusCode: 200,
+		Body: `{"status": "success", "message": "V-COMP", "data": {"id": 4410, "flwRef": "FLW-PENDING", ` +
+			`"txRef": "ussd-4", "status": "pending", "chargeResponseCode": "02", "amount": 1000, "currency": "NGN"}}`,
+	}
+	server.Script(ravetest.ChargePath, pending, pending)
+
+	ussdResponse, err := client.ChargeUSSDTyped(ctx, USSDChargeRequest{
+		Customer: customer, AccountBank: "058", Amount: MustMoney("1000", "NGN"), TxRef: "ussd-4", OrderRef: "order-4",
+	})
+	assertEqual(t, err, ErrNoDialString)
+	if ussdResponse == nil {
+		t.Fatal("Expected the USSD charge to be returned with the error")
+	}
+	assertEqual(t, ussdResponse.Data.FlwRef, "FLW-PENDING")
+	assertEqual(t, ussdResponse.DialString, "")
+
+	mcashResponse, err := client.ChargeMcashTyped(ctx, McashChargeRequest{
+		Customer: customer, Amount: MustMoney("1000", "NGN"), TxRef: "mcash-4", OrderRef: "order-4",
+	})
+	assertEqual(t, err, ErrNoPaymentCode)
+	if mcashResponse == nil {
+		t.Fatal("Expected the Mcash charge to be returned with the error")
+	}
+	assertEqual(t, mcashResponse.Data.FlwRef, "FLW-PENDING")
+	assertEqual(t, mcashResponse.PaymentCode, "")
+}
+
+// Missing parameters and currencies other than NGN should be reported before any request is made
+func TestAlternativePaymentParameters(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	_, err := client.ChargeUSSDTyped(ctx, USSDChargeRequest{
+		Customer: customer, Amount: MustMoney("1000", "NGN"), TxRef: "ussd-2", OrderRef: "order-2",
+	})
+	assertEqual(t, err.Error(), "\"accountbank\" is a required parameter for \"ChargeUSSDTyped\"")
+
+	_, err = client.ChargeMcashTyped(ctx, McashChargeRequest{
+		Customer: customer, Amount: MustMoney("1000", "NGN"), TxRef: "mcash-3",
+	})
+	assertEqual(t, err.Error(), "\"orderRef\" is a required parameter for \"ChargeMcashTyped\"")
+
+	_, err = client.ChargeUSSDTyped(ctx, USSDChargeRequest{
+		Customer: customer, AccountBank: "058", Amount: MustMoney("10", "USD"), TxRef: "ussd-3", OrderRef: "order-3",
+	})
+	if !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency got %v", err)
+	}
+
+	assertEqual(t, server.Requests(ravetest.ChargePath), 0)
+}
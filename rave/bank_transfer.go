@@ -0,0 +1,142 @@
+/* This file contains the functions/methods for pay with bank transfer charges */
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parameters required by every bank transfer charge
+var bankTransferParameters = []string{"amount", "email", "phonenumber", "firstname", "lastname", "IP", "txRef"}
+
+// bankTransferMethod : Payload of bank transfer charges
+var bankTransferMethod = paymentMethod{
+	name: "Bank transfer", paymentType: "banktransfer", country: "NG", currencies: []string{"NGN"},
+	flags: []string{"is_bank_transfer"},
+}
+
+// DefaultTransferPollInterval : How often AwaitBankTransfer verifies a transfer when no interval is given
+const DefaultTransferPollInterval = 10 * time.Second
+
+// ErrBankTransferExpired : Returned by AwaitBankTransfer when the account expires before the transfer lands
+var ErrBankTransferExpired = errors.New("The account expired before the transfer was received")
+
+// ChargeBankTransfer : Create a charge the customer pays by transferring to a temporary account.
+// payment_type and is_bank_transfer are set for you and the currency defaults to NGN.
+func (r Rave) ChargeBankTransfer(data map[string]interface{}) ([]byte, error) {
+	return r.ChargeBankTransferContext(context.Background(), data)
+}
+
+// ChargeBankTransferContext : Same as ChargeBankTransfer but honors the cancellation and deadline of ctx
+func (r Rave) ChargeBankTransferContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	chargeData, err := methodCharge("ChargeBankTransfer", bankTransferMethod, bankTransferParameters, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.chargeMethod(ctx, chargeData)
+}
+
+// ChargeBankTransferTyped : Typed version of ChargeBankTransfer
+func (r Rave) ChargeBankTransferTyped(ctx context.Context, request BankTransferRequest) (*BankTransferResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+	request.Currency = currency
+
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeData, err := methodCharge("ChargeBankTransferTyped", bankTransferMethod, bankTransferParameters, data)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.chargeMethod(ctx, chargeData)
+	if err != nil {
+		return nil, err
+	}
+
+	// the response doesn't contain the currency, the amount is in the currency of the charge
+	transferResponse := &BankTransferResponse{}
+	transferResponse.Data.Amount.Currency = fmt.Sprint(chargeData["currency"])
+
+	err = decodeResponse(response, transferResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return transferResponse, nil
+}
+
+// AwaitBankTransfer : Verify the transfer every interval (DefaultTransferPollInterval if it's 0)
+// until it's verified, Rave reports it as failed, the account expires or ctx is done.
+// amount is the amount the customer had to pay, it must not be taken from the response.
+func (r Rave) AwaitBankTransfer(
+	ctx context.Context, transfer *BankTransferResponse, amount Money, interval time.Duration,
+) (*VerifyResponse, error) {
+	if interval <= 0 {
+		interval = DefaultTransferPollInterval
+	}
+
+	request := VerifyRequest{FlwRef: transfer.Data.FlwRef, Amount: amount, Currency: amount.Currency, Normalize: "1"}
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRequiredParameters("AwaitBankTransfer", data, verifyParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		response, verifyResponse, err := r.transactionStatus(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.ToLower(verifyResponse.Data.Status) {
+		case "successful":
+			err = verifyTransaction(r.getVerificationRules(), data, response)
+			if err != nil {
+				return nil, err
+			}
+
+			return verifyResponse, nil
+
+		case "failed", "error", "cancelled":
+			return nil, fmt.Errorf(
+				"%w: %s", ErrTransactionFailed, firstNonEmpty(verifyResponse.Data.ChargeMessage, verifyResponse.Data.Status),
+			)
+		}
+
+		// the transfer is checked one last time when the account expires
+		wait := interval
+		if expiresAt := transfer.Data.ExpiresAt; !expiresAt.IsZero() {
+			untilExpiry := time.Until(expiresAt)
+			if untilExpiry <= 0 {
+				return nil, ErrBankTransferExpired
+			}
+
+			if untilExpiry < wait {
+				wait = untilExpiry
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
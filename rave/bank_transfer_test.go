@@ -0,0 +1,146 @@
+// Tests for pay with bank transfer charges
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// The account and it's expiry should be returned and AwaitBankTransfer should return once the transfer lands
+func TestChargeBankTransfer(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	transfer, err := client.ChargeBankTransferTyped(ctx, BankTransferRequest{
+		Customer: customer, Amount: MustMoney("2500", "NGN"), TxRef: "transfer-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, transfer.Data.AccountNumber, "7800000001")
+	assertEqual(t, transfer.Data.BankName, "RAVETEST BANK")
+	assertEqual(t, transfer.Data.Amount, MustMoney("2500", "NGN"))
+	if until := time.Until(transfer.Data.ExpiresAt); until <= 0 || until > ravetest.DefaultTransferExpiry {
+		t.Fatalf("Expected the account to expire within %s got %s", ravetest.DefaultTransferExpiry, transfer.Data.ExpiresAt)
+	}
+
+	payload := server.Charges()[0]
+	assertEqual(t, payload["payment_type"], "banktransfer")
+	assertEqual(t, fmt.Sprint(payload["is_bank_transfer"]), "1")
+
+	time.AfterFunc(50*time.Millisecond, func() { server.ApprovePayment(transfer.Data.FlwRef) })
+
+	verifyResponse, err := client.AwaitBankTransfer(ctx, transfer, MustMoney("2500", "NGN"), 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, verifyResponse.Data.FlwRef, transfer.Data.FlwRef)
+	assertEqual(t, verifyResponse.Data.ChargedAmount, MustMoney("2500", "NGN"))
+}
+
+// AwaitBankTransfer should verify the amount the merchant expects, not the amount in the response
+func TestAwaitBankTransferAmount(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	transfer, err := client.ChargeBankTransferTyped(ctx, BankTransferRequest{
+		Customer: customer, Amount: MustMoney("2500", "NGN"), TxRef: "transfer-4",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, server.ApprovePayment(transfer.Data.FlwRef), true)
+
+	_, err = client.AwaitBankTransfer(ctx, transfer, MustMoney("3000", "NGN"), 10*time.Millisecond)
+	verificationError := &VerificationError{}
+	if !errors.As(err, &verificationError) || !verificationError.Failed(RuleChargedAmount) {
+		t.Fatalf("Expected the charged amount rule to fail got %v", err)
+	}
+
+	// a response without the amount can't weaken the check
+	transfer.Data.Amount = Money{}
+	_, err = client.AwaitBankTransfer(ctx, transfer, MustMoney("3000", "NGN"), 10*time.Millisecond)
+	if !errors.As(err, &verificationError) || !verificationError.Failed(RuleChargedAmount) {
+		t.Fatalf("Expected the charged amount rule to fail got %v", err)
+	}
+}
+
+// AwaitBankTransfer should give up when the account expires or ctx is done
+func TestAwaitBankTransferExpiry(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	server.SetTransferExpiry(100 * time.Millisecond)
+	request := BankTransferRequest{Customer: customer, Amount: MustMoney("2500", "NGN"), TxRef: "transfer-2"}
+	transfer, err := client.ChargeBankTransferTyped(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the account expires before the next poll, it's checked once more at expiry
+	requests := server.Requests(ravetest.VerifyPath)
+	_, err = client.AwaitBankTransfer(ctx, transfer, MustMoney("2500", "NGN"), time.Hour)
+	if !errors.Is(err, ErrBankTransferExpired) {
+		t.Fatalf("Expected ErrBankTransferExpired got %v", err)
+	}
+	assertEqual(t, server.Requests(ravetest.VerifyPath)-requests, 2)
+
+	server.SetTransferExpiry(ravetest.DefaultTransferExpiry)
+	request.TxRef = "transfer-3"
+	transfer, err = client.ChargeBankTransferTyped(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.AwaitBankTransfer(timeoutCtx, transfer, MustMoney("2500", "NGN"), 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded got %v", err)
+	}
+}
+
+// The expiry date should be decoded from timestamps and dates
+func TestBankTransferExpiryDate(t *testing.T) {
+	t.Parallel()
+
+	expected := time.Date(2018, 11, 29, 14, 57, 16, 0, time.UTC)
+	for _, expiry := range []string{`1543503436`, `1543503436000`, `"2018-11-29T14:57:16Z"`, `"2018-11-29 14:57:16"`} {
+		data := BankTransferData{}
+		if err := data.UnmarshalJSON([]byte(`{"accountnumber": "7824822527", "expiry_date": ` + expiry + `}`)); err != nil {
+			t.Fatal(err)
+		}
+
+		if !data.ExpiresAt.Equal(expected) {
+			t.Errorf("Expected %s to be decoded as %s got %s", expiry, expected, data.ExpiresAt)
+		}
+		assertEqual(t, data.AccountNumber, "7824822527")
+	}
+
+	err := (&BankTransferData{}).UnmarshalJSON([]byte(`{"expiry_date": "tomorrow"}`))
+	if err == nil {
+		t.Error("Expected an invalid expiry date to be rejected")
+	}
+}
@@ -0,0 +1,184 @@
+/*
+This file contains the functions/methods for transfer beneficiaries (saved bank accounts)
+
+Before a beneficiary is created it's bank code is checked against the list of
+banks (cached for BankListTTL) and the account is resolved, so transfers are
+never made to accounts that don't exist. Pay a beneficiary by setting
+"recipient" (TransferRequest.BeneficiaryID) to it's ID in InitiateTransfer.
+*/
+
+package rave
+
+import (
+	"context"
+	"fmt"
+)
+
+// parameters required to create a beneficiary
+var beneficiaryParameters = []string{"account_number", "account_bank"}
+
+// CreateBeneficiary : Save a bank account so transfers can be made to it with it's ID.
+// The bank code is checked with the list of banks and the account is resolved first.
+func (r Rave) CreateBeneficiary(data map[string]interface{}) ([]byte, error) {
+	return r.CreateBeneficiaryContext(context.Background(), data)
+}
+
+// CreateBeneficiaryContext : Same as CreateBeneficiary but honors the cancellation and deadline of ctx
+func (r Rave) CreateBeneficiaryContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	err := checkRequiredParameters("CreateBeneficiary", data, beneficiaryParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.createBeneficiary(ctx, data)
+}
+
+// CreateBeneficiaryTyped : Typed version of CreateBeneficiary
+func (r Rave) CreateBeneficiaryTyped(ctx context.Context, request BeneficiaryRequest) (*BeneficiaryResponse, error) {
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRequiredParameters("CreateBeneficiaryTyped", data, beneficiaryParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.createBeneficiary(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	beneficiaryResponse := &BeneficiaryResponse{}
+	err = decodeResponse(response, beneficiaryResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return beneficiaryResponse, nil
+}
+
+// createBeneficiary : Check the bank code, resolve the account and send a beneficiary request to Rave.
+// It isn't retried because Rave would create the beneficiary twice.
+func (r Rave) createBeneficiary(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	accountNumber, bankCode := fmt.Sprint(data["account_number"]), fmt.Sprint(data["account_bank"])
+
+	_, err = r.findBank(ctx, bankCode)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.ResolveAccount(ctx, accountNumber, bankCode)
+	if err != nil {
+		return nil, err
+	}
+
+	data["seckey"] = secretKey
+	URL := r.getBaseURL() + "/v2/gpx/transfers/beneficiaries/create"
+
+	return r.makePostRequest(ctx, URL, data)
+}
+
+// ListBeneficiaries : List your beneficiaries a page at a time, "page" is optional
+func (r Rave) ListBeneficiaries(data map[string]interface{}) ([]byte, error) {
+	return r.ListBeneficiariesContext(context.Background(), data)
+}
+
+// ListBeneficiariesContext : Same as ListBeneficiaries but honors the cancellation and deadline of ctx
+func (r Rave) ListBeneficiariesContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	return r.listBeneficiaries(ctx, data)
+}
+
+// ListBeneficiariesTyped : Typed version of ListBeneficiaries
+func (r Rave) ListBeneficiariesTyped(ctx context.Context, request ListBeneficiariesRequest) (*BeneficiaryListResponse, error) {
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.listBeneficiaries(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := &BeneficiaryListResponse{}
+	err = decodeResponse(response, listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return listResponse, nil
+}
+
+// listBeneficiaries : Sends a request for a page of beneficiaries
+func (r Rave) listBeneficiaries(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{"seckey": secretKey}
+	for key, value := range data {
+		query[key] = value
+	}
+
+	URL := r.getBaseURL() + "/v2/gpx/transfers/beneficiaries"
+
+	return r.withRetries(ctx, nil, func() ([]byte, error) {
+		return r.makeGetRequest(ctx, URL, query)
+	})
+}
+
+// DeleteBeneficiary : Delete the beneficiary with "id"
+func (r Rave) DeleteBeneficiary(data map[string]interface{}) ([]byte, error) {
+	return r.DeleteBeneficiaryContext(context.Background(), data)
+}
+
+// DeleteBeneficiaryContext : Same as DeleteBeneficiary but honors the cancellation and deadline of ctx
+func (r Rave) DeleteBeneficiaryContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	err := checkRequiredParameters("DeleteBeneficiary", data, []string{"id"})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.deleteBeneficiary(ctx, data)
+}
+
+// DeleteBeneficiaryTyped : Typed version of DeleteBeneficiary
+func (r Rave) DeleteBeneficiaryTyped(ctx context.Context, id int) (*DeleteBeneficiaryResponse, error) {
+	if id <= 0 {
+		return nil, &ParameterError{Parameter: "id", Method: "DeleteBeneficiaryTyped"}
+	}
+
+	response, err := r.deleteBeneficiary(ctx, map[string]interface{}{"id": id})
+	if err != nil {
+		return nil, err
+	}
+
+	deleteResponse := &DeleteBeneficiaryResponse{}
+	err = decodeResponse(response, deleteResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return deleteResponse, nil
+}
+
+// deleteBeneficiary : Sends a request to delete a beneficiary to Rave
+func (r Rave) deleteBeneficiary(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	data["seckey"] = secretKey
+	URL := r.getBaseURL() + "/v2/gpx/transfers/beneficiaries/delete"
+
+	return r.makePostRequest(ctx, URL, data)
+}
@@ -0,0 +1,189 @@
+// Tests for transfer beneficiaries
+
+package rave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// Beneficiaries should be created, paid by their ID and deleted
+func TestBeneficiaries(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	created, err := client.CreateBeneficiaryTyped(ctx, BeneficiaryRequest{AccountNumber: "0690000040", AccountBank: "044"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, created.Data.FullName, ravetest.AccountName)
+	assertEqual(t, created.Data.BankName, "ACCESS BANK NIGERIA")
+
+	request := transferRequest("payout-beneficiary")
+	request.AccountBank, request.AccountNumber, request.BeneficiaryID = "", "", created.Data.ID
+	if _, err := client.InitiateTransferTyped(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+
+	payout, _ := server.Payout("payout-beneficiary")
+	assertEqual(t, payout.AccountNumber, "0690000040")
+	assertEqual(t, payout.AccountBank, "044")
+
+	beneficiaries, err := client.ListBeneficiariesTyped(ctx, ListBeneficiariesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, beneficiaries.Data.PageInfo.Total, 1)
+	assertEqual(t, beneficiaries.Data.Beneficiaries[0].ID, created.Data.ID)
+
+	if _, err := client.DeleteBeneficiaryTyped(ctx, created.Data.ID); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(server.Beneficiaries()), 0)
+
+	// the beneficiary can't be paid once it's deleted
+	request.Reference = "payout-deleted"
+	_, err = client.InitiateTransferTyped(ctx, request)
+	apiError := &APIError{}
+	if !errors.As(err, &apiError) {
+		t.Errorf("Expected an *APIError got %v", err)
+	}
+
+	request.BeneficiaryID = 0
+	_, err = client.InitiateTransferTyped(ctx, request)
+	assertEqual(t, err.Error(), "\"account_bank\" is a required parameter for \"InitiateTransferTyped\"")
+}
+
+// Beneficiaries should only be created for known banks and accounts that can be resolved
+func TestCreateBeneficiaryValidation(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	_, err := client.CreateBeneficiaryTyped(ctx, BeneficiaryRequest{AccountNumber: "0690000040", AccountBank: "999"})
+	if !errors.Is(err, ErrUnknownBank) || !IsValidationError(err) {
+		t.Errorf("Expected ErrUnknownBank got %v", err)
+	}
+
+	_, err = client.CreateBeneficiary(map[string]interface{}{
+		"account_number": ravetest.UnresolvableAccount, "account_bank": "058",
+	})
+	if !errors.Is(err, ErrAccountNotResolved) {
+		t.Errorf("Expected ErrAccountNotResolved got %v", err)
+	}
+
+	// the list of banks is only fetched once
+	assertEqual(t, server.Requests(ravetest.BanksPath), 1)
+	assertEqual(t, server.Requests(ravetest.ResolveAccountPath), 1)
+	assertEqual(t, server.Requests(ravetest.BeneficiaryPath), 0)
+
+	resolved, err := client.ResolveAccount(ctx, "0690000040", "058")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, resolved.AccountName, ravetest.AccountName)
+}
+
+// The live and test lists of banks should be cached separately
+func TestCachedBanksByEnvironment(t *testing.T) {
+	t.Parallel()
+
+	liveServer, testServer := ravetest.NewServer(), ravetest.NewServer()
+	defer liveServer.Close()
+	defer testServer.Close()
+
+	client := NewClient(WithBaseURLs(liveServer.URL, testServer.URL), WithRetryPolicy(NoRetries))
+	liveClient := client
+	liveClient.Live = true
+	ctx := context.Background()
+
+	for _, c := range []Rave{client, liveClient, client, liveClient} {
+		banks, err := c.CachedBanks(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, len(banks), len(ravetest.Banks))
+	}
+
+	assertEqual(t, testServer.Requests(ravetest.BanksPath), 1)
+	assertEqual(t, liveServer.Requests(ravetest.BanksPath), 1)
+}
+
+// Callers waiting for the list of banks shouldn't be held by a slow fetch past their own ctx
+func TestCachedBanksSlowFetch(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	body, _ := json.Marshal(ravetest.Banks)
+	slow := ravetest.Response{StatusCode: 200, Body: string(body), Delay: 300 * time.Millisecond}
+	server.Script(ravetest.BanksPath, slow)
+
+	client := NewClient(WithBaseURLs(server.URL, server.URL), WithRetryPolicy(NoRetries))
+	ctx := context.Background()
+
+	fetched := make(chan error)
+	go func() {
+		_, err := client.CachedBanks(ctx)
+		fetched <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	if _, err := client.CachedBanks(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded got %v", err)
+	}
+	if waited := time.Since(started); waited > 200*time.Millisecond {
+		t.Fatalf("Expected to stop waiting with the ctx, waited %s", waited)
+	}
+
+	if err := <-fetched; err != nil {
+		t.Fatal(err)
+	}
+
+	banks, err := client.CachedBanks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(banks), len(ravetest.Banks))
+	assertEqual(t, server.Requests(ravetest.BanksPath), 1)
+
+	// a caller waiting on a fetch whose ctx is done fetches the list itself
+	other := NewClient(WithBaseURLs(server.URL, server.URL), WithRetryPolicy(NoRetries))
+	server.Script(ravetest.BanksPath, slow)
+
+	cancelCtx, cancelFetch := context.WithCancel(ctx)
+	go func() {
+		_, err := other.CachedBanks(cancelCtx)
+		fetched <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	time.AfterFunc(50*time.Millisecond, cancelFetch)
+
+	banks, err = other.CachedBanks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(banks), len(ravetest.Banks))
+	if err := <-fetched; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled got %v", err)
+	}
+}
@@ -0,0 +1,346 @@
+/*
+This file contains ChargeSession, a state machine that drives a card charge
+through every authorization step Rave asks for.
+
+	session, err := client.StartCharge(ctx, request)
+	for err == nil && !session.Done() {
+		switch session.Step {
+		case rave.StepNeedPIN:
+			err = session.SubmitPIN(ctx, askForPIN())
+		case rave.StepNeedOTP:
+			err = session.SubmitOTP(ctx, askForOTP(session.Message))
+		...
+		}
+	}
+
+Sessions can be serialized to JSON between HTTP requests and resumed with
+ResumeChargeSession. Card details (including the PIN) are never serialized,
+set session.Request.Card again before submitting a PIN or billing address.
+*/
+
+package rave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ChargeStep : What a ChargeSession needs to continue
+type ChargeStep string
+
+// Steps of a ChargeSession
+const (
+	// StepNeedPIN : Submit the card PIN with SubmitPIN
+	StepNeedPIN ChargeStep = "need_pin"
+
+	// StepNeedOTP : Submit the OTP sent to the customer with SubmitOTP
+	StepNeedOTP ChargeStep = "need_otp"
+
+	// StepNeedRedirect : Send the customer to AuthURL then call CheckStatus once they're redirected back
+	StepNeedRedirect ChargeStep = "need_redirect"
+
+	// StepNeedBillingAddress : Submit the card's billing address with SubmitBillingAddress
+	StepNeedBillingAddress ChargeStep = "need_billing_address"
+
+	// StepCompleted : The charge was successful
+	StepCompleted ChargeStep = "completed"
+
+	// StepFailed : The charge was declined or failed, see Message
+	StepFailed ChargeStep = "failed"
+)
+
+// ErrUnexpectedChargeStep : Returned when an input is submitted that the session doesn't need
+var ErrUnexpectedChargeStep = errors.New("The charge session doesn't need this input")
+
+// ErrChargeSessionDetached : Returned when a session wasn't created by StartCharge or ResumeChargeSession
+var ErrChargeSessionDetached = errors.New("The charge session isn't attached to a client, use ResumeChargeSession")
+
+// ChargeSession : A card charge and the next step required to complete it
+type ChargeSession struct {
+	Step ChargeStep `json:"step"`
+
+	// The charge being made, the card details are left out when the session is serialized
+	Request CardChargeRequest `json:"request"`
+
+	// Auth model suggested by Rave (PIN, NOAUTH_INTERNATIONAL, AVS_VBVSECURECODE)
+	SuggestedAuth string `json:"suggested_auth,omitempty"`
+
+	FlwRef  string `json:"flw_ref,omitempty"`
+	AuthURL string `json:"auth_url,omitempty"`
+
+	// Instructions for the customer (e.g where the OTP was sent) or the reason the charge failed
+	Message string `json:"message,omitempty"`
+
+	// Transaction details from the last response, the charge token is left out when the session is serialized
+	Charge *ChargeData `json:"charge,omitempty"`
+
+	// The card details were encrypted in the browser, see StartEncryptedCharge
+	Encrypted bool `json:"encrypted,omitempty"`
+
+	client *Rave
+}
+
+// StartCharge : Send a card charge and return a session with the next step required to complete it.
+// An error is returned (and Step is StepFailed) if the charge is declined.
+func (r Rave) StartCharge(ctx context.Context, request CardChargeRequest) (*ChargeSession, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+	request.Currency = currency
+
+	session := &ChargeSession{Request: request, client: &r}
+
+	return session, session.charge(ctx, "StartCharge")
+}
+
+// ResumeChargeSession : Restore a session serialized with json.Marshal
+func (r Rave) ResumeChargeSession(data []byte) (*ChargeSession, error) {
+	session := &ChargeSession{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+
+	session.client = &r
+
+	return session, nil
+}
+
+// Done : Reports whether the session completed or failed
+func (s *ChargeSession) Done() bool {
+	return s.Step == StepCompleted || s.Step == StepFailed
+}
+
+// SubmitPIN : Charge the card again with it's PIN
+func (s *ChargeSession) SubmitPIN(ctx context.Context, pin string) error {
+	if err := s.expect(StepNeedPIN, "a PIN"); err != nil {
+		return err
+	}
+
+	if s.Encrypted {
+		return fmt.Errorf("%w: the PIN of an encrypted charge must be submitted with SubmitEncrypted", ErrUnexpectedChargeStep)
+	}
+
+	s.Request.Pin = pin
+	s.Request.SuggestedAuth = "PIN"
+
+	return s.charge(ctx, "SubmitPIN")
+}
+
+// SubmitBillingAddress : Charge the card again with it's billing address
+func (s *ChargeSession) SubmitBillingAddress(ctx context.Context, address BillingAddress) error {
+	if err := s.expect(StepNeedBillingAddress, "a billing address"); err != nil {
+		return err
+	}
+
+	if s.Encrypted {
+		return fmt.Errorf("%w: the billing address of an encrypted charge must be submitted with SubmitEncrypted", ErrUnexpectedChargeStep)
+	}
+
+	s.Request.BillingAddress = address
+	s.Request.SuggestedAuth = s.SuggestedAuth
+
+	return s.charge(ctx, "SubmitBillingAddress")
+}
+
+// SubmitOTP : Validate the charge with the OTP sent to the customer.
+// The session stays at StepNeedOTP if Rave rejects the OTP.
+func (s *ChargeSession) SubmitOTP(ctx context.Context, otp string) error {
+	if err := s.expect(StepNeedOTP, "an OTP"); err != nil {
+		return err
+	}
+
+	response, err := s.client.ValidateChargeTyped(ctx, ValidateChargeRequest{TransactionReference: s.FlwRef, OTP: otp})
+	if err != nil {
+		return err
+	}
+
+	s.advance(&response.Data.Tx)
+
+	return nil
+}
+
+// CheckStatus : Verify the charge after the customer was redirected back from AuthURL.
+// The session stays at StepNeedRedirect while the transaction is pending.
+func (s *ChargeSession) CheckStatus(ctx context.Context) error {
+	if err := s.expect(StepNeedRedirect, "a status check"); err != nil {
+		return err
+	}
+
+	request := VerifyRequest{FlwRef: s.FlwRef, Amount: s.Request.Amount, Currency: s.Request.Currency, Normalize: "1"}
+	data, err := structToMap(request)
+	if err != nil {
+		return err
+	}
+
+	// the transaction is only verified once Rave reports it as finished
+	response, verifyResponse, err := s.client.transactionStatus(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(verifyResponse.Data.Status) {
+	case "successful":
+		err = verifyTransaction(s.client.getVerificationRules(), data, response)
+		if err != nil {
+			s.fail(err.Error())
+			return err
+		}
+
+		s.Step = StepCompleted
+		s.Message = verifyResponse.Data.ChargeMessage
+
+	case "failed", "error", "cancelled":
+		s.fail(firstNonEmpty(verifyResponse.Data.ChargeMessage, verifyResponse.Data.Status))
+	}
+
+	return nil
+}
+
+// MarshalJSON : Serialize the session without the card details and the card's charge token
+func (s ChargeSession) MarshalJSON() ([]byte, error) {
+	type chargeSession ChargeSession
+
+	s.Request.Card = Card{}
+	if s.Charge != nil {
+		// the token can charge the card again without the customer, the session's charge isn't changed
+		charge := *s.Charge
+		charge.ChargeToken = ChargeToken{}
+		s.Charge = &charge
+	}
+
+	return json.Marshal(chargeSession(s))
+}
+
+// UnmarshalJSON : Restore a serialized session, the amount is decoded in the currency of the request
+func (s *ChargeSession) UnmarshalJSON(body []byte) error {
+	type chargeSession ChargeSession
+
+	raw := struct {
+		Request json.RawMessage `json:"request"`
+	}{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return err
+	}
+
+	currency := ""
+	if len(raw.Request) > 0 {
+		var err error
+		if currency, err = currencyOf(raw.Request); err != nil {
+			return err
+		}
+	}
+
+	session := chargeSession{Request: CardChargeRequest{Amount: Money{Currency: currency}}}
+	if err := json.Unmarshal(body, &session); err != nil {
+		return err
+	}
+
+	*s = ChargeSession(session)
+
+	return nil
+}
+
+// expect : Check that the session is attached and waiting for step
+func (s *ChargeSession) expect(step ChargeStep, input string) error {
+	if s.client == nil {
+		return ErrChargeSessionDetached
+	}
+
+	if s.Step != step {
+		return fmt.Errorf("%w: can't submit %s when the session is at \"%s\"", ErrUnexpectedChargeStep, input, s.Step)
+	}
+
+	return nil
+}
+
+// charge : Send the charge and move to the next step. Declined charges
+// fail the session, other errors (e.g network errors) leave it unchanged.
+func (s *ChargeSession) charge(ctx context.Context, methodName string) error {
+	chargeData, err := structToMap(s.Request)
+	if err != nil {
+		return err
+	}
+
+	err = checkRequiredParameters(methodName, chargeData, cardChargeParameters)
+	if err != nil {
+		return err
+	}
+
+	if requiresBillingAddress(s.Request.SuggestedAuth) {
+		err = checkBillingAddress(methodName, s.Request.BillingAddress, s.Request.SuggestedAuth)
+		if err != nil {
+			return err
+		}
+	}
+
+	postData, err := s.client.setUpCharge(chargeData)
+	if err != nil {
+		return err
+	}
+
+	response, err := s.client.charge(ctx, postData, chargeData["txRef"])
+
+	return s.handleCharge(response, err)
+}
+
+// handleCharge : Move to the next step from the response to a charge
+func (s *ChargeSession) handleCharge(response []byte, err error) error {
+	if err != nil {
+		apiError := &APIError{}
+		if errors.As(err, &apiError) && !apiError.Retryable() {
+			s.fail(apiError.Message)
+		}
+
+		return err
+	}
+
+	chargeResponse := &ChargeResponse{}
+	err = decodeResponse(response, chargeResponse)
+	if err != nil {
+		return err
+	}
+
+	s.advance(&chargeResponse.Data)
+
+	return nil
+}
+
+// advance : Work out the next step from the transaction details Rave returned
+func (s *ChargeSession) advance(data *ChargeData) {
+	s.Charge = data
+	s.FlwRef = firstNonEmpty(data.FlwRef, s.FlwRef)
+
+	suggestedAuth := strings.ToUpper(data.SuggestedAuth)
+
+	switch {
+	case suggestedAuth == "PIN":
+		s.Step, s.SuggestedAuth = StepNeedPIN, suggestedAuth
+
+	case requiresBillingAddress(suggestedAuth):
+		s.Step, s.SuggestedAuth = StepNeedBillingAddress, suggestedAuth
+
+	case data.ChargeResponseCode == "00" || data.ChargeResponseCode == "0":
+		s.Step = StepCompleted
+		s.Message = data.ChargeResponseMessage
+
+	case data.AuthFlow() == AuthFlowRedirect:
+		s.Step, s.AuthURL = StepNeedRedirect, data.AuthURL
+
+	case data.AuthFlow() == AuthFlowOTP:
+		s.Step = StepNeedOTP
+		s.Message = data.ChargeResponseMessage
+
+	default:
+		s.fail(firstNonEmpty(data.ChargeResponseMessage, "The charge failed"))
+	}
+}
+
+// fail : Mark the session as failed
+func (s *ChargeSession) fail(message string) {
+	s.Step = StepFailed
+	s.Message = message
+}
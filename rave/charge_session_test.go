@@ -0,0 +1,228 @@
+// Tests for ChargeSession, driven by the fake server in ravetest
+
+package rave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// newSessionClient : Returns a client for server
+func newSessionClient(server *ravetest.Server) Rave {
+	return NewClient(
+		WithKeys(server.PublicKey, server.SecretKey),
+		WithBaseURLs("", server.URL),
+		WithHTTPClient(server.Client()),
+		WithRetryPolicy(NoRetries),
+	)
+}
+
+// sessionCharge : Returns a charge for cardNo without a PIN
+func sessionCharge(cardNo string) CardChargeRequest {
+	return CardChargeRequest{
+		Card:        Card{CardNo: cardNo, CVV: "789", ExpiryMonth: "09", ExpiryYear: "25"},
+		Customer:    customer,
+		Amount:      MustMoney("1052.50", "NGN"),
+		TxRef:       "session-" + cardNo[len(cardNo)-4:],
+		RedirectURL: "https://example.com/callback",
+	}
+}
+
+// resume : Serialize the session and resume it like a new HTTP request would
+func resume(t *testing.T, client Rave, session *ChargeSession) *ChargeSession {
+	t.Helper()
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), session.Request.CardNo) || strings.Contains(string(data), "cvv") {
+		t.Fatalf("Card details shouldn't be serialized: %s", data)
+	}
+
+	resumed, err := client.ResumeChargeSession(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, resumed.Request.Amount, session.Request.Amount)
+
+	return resumed
+}
+
+func TestChargeSessionPINAndOTP(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	request := sessionCharge(ravetest.PinCard)
+	session, err := client.StartCharge(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepNeedPIN)
+
+	// the card details must be set again after resuming
+	session = resume(t, client, session)
+	err = session.SubmitPIN(ctx, "3310")
+	parameterError := &ParameterError{}
+	if !errors.As(err, &parameterError) || parameterError.Parameter != "cardno" {
+		t.Fatalf("Expected the card to be required got %v", err)
+	}
+
+	session.Request.Card = request.Card
+	if err := session.SubmitPIN(ctx, "3310"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepNeedOTP)
+
+	if err := session.SubmitPIN(ctx, "3310"); !errors.Is(err, ErrUnexpectedChargeStep) {
+		t.Errorf("Expected ErrUnexpectedChargeStep got %v", err)
+	}
+
+	session = resume(t, client, session)
+	if err := session.SubmitOTP(ctx, "00000"); err == nil {
+		t.Fatal("Expected an invalid OTP to be rejected")
+	}
+	assertEqual(t, session.Step, StepNeedOTP)
+
+	if err := session.SubmitOTP(ctx, ravetest.ValidOTP); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepCompleted)
+	assertEqual(t, session.Charge.ChargedAmount, MustMoney("1052.50", "NGN"))
+	assertEqual(t, session.Done(), true)
+}
+
+func TestChargeSession3DSecure(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	session, err := client.StartCharge(ctx, sessionCharge(ravetest.ThreeDSecureCard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepNeedRedirect)
+	assertEqual(t, session.AuthURL, server.AuthURL(session.FlwRef))
+
+	// still pending until the customer completes the redirect
+	if err := session.CheckStatus(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepNeedRedirect)
+
+	httpClient := server.Client()
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	response, err := httpClient.Get(session.AuthURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	session = resume(t, client, session)
+	if err := session.CheckStatus(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepCompleted)
+}
+
+func TestChargeSessionBillingAddress(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+	address := BillingAddress{
+		BillingZip: "07205", BillingCity: "Hillside", Address: "470 Mundet PI", BillingState: "NJ", BillingCountry: "US",
+	}
+
+	for card, step := range map[string]ChargeStep{ravetest.InternationalCard: StepCompleted, ravetest.AVSCard: StepNeedRedirect} {
+		session, err := client.StartCharge(ctx, sessionCharge(card))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, session.Step, StepNeedBillingAddress)
+
+		if err := session.SubmitBillingAddress(ctx, address); err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, session.Step, step)
+	}
+}
+
+func TestChargeSessionDeclined(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	session, err := client.StartCharge(context.Background(), sessionCharge(ravetest.DeclinedCard))
+	if !IsDeclined(err) {
+		t.Errorf("Expected a declined charge got %v", err)
+	}
+	assertEqual(t, session.Step, StepFailed)
+	assertEqual(t, session.Message, "Fraudulent. Transaction")
+
+	// network errors leave the session unchanged so the step can be retried
+	server.Script(ravetest.ChargePath, ravetest.ServerError())
+	session, err = client.StartCharge(context.Background(), sessionCharge(ravetest.PinCard))
+	if !IsRetryable(err) {
+		t.Errorf("Expected a retryable error got %v", err)
+	}
+	assertEqual(t, session.Step, ChargeStep(""))
+
+	if err := (&ChargeSession{Step: StepNeedPIN}).SubmitPIN(context.Background(), "3310"); err != ErrChargeSessionDetached {
+		t.Errorf("Expected ErrChargeSessionDetached got %v", err)
+	}
+}
+
+// The charge token of a completed session shouldn't be serialized
+func TestChargeSessionMarshalWithoutToken(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	session, err := client.StartCharge(context.Background(), sessionCharge(ravetest.NoAuthCard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepCompleted)
+
+	chargeToken := session.Charge.ChargeToken.EmbedToken
+	if chargeToken == "" {
+		t.Fatal("Expected the completed charge to return a token")
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), chargeToken) {
+		t.Fatalf("The charge token shouldn't be serialized: %s", data)
+	}
+	assertEqual(t, session.Charge.ChargeToken.EmbedToken, chargeToken)
+}
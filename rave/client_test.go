@@ -0,0 +1,143 @@
+// Tests for the client options
+
+package rave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// echoServer : Returns a server that echoes the keys and User-Agent it received
+func echoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(req.Body).Decode(&body)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success", "message": req.Header.Get("User-Agent"),
+			"data": map[string]interface{}{"flwRef": body["SECKEY"], "txRef": body["flwRef"]},
+		})
+	}))
+}
+
+// Clients with different keys should not affect each other when used concurrently
+func TestClientsWithDifferentKeys(t *testing.T) {
+	t.Parallel()
+
+	server := echoServer()
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for _, secretKey := range []string{"FLWSECK-merchant-one-X", "FLWSECK-merchant-two-X"} {
+		client := NewClient(
+			WithKeys("FLWPUBK-public-X", secretKey),
+			WithBaseURLs("", server.URL),
+			WithHTTPClient(server.Client()),
+		)
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(client Rave, secretKey string) {
+				defer wg.Done()
+
+				response, err := client.CaptureTyped(context.Background(), CaptureRequest{FlwRef: "FLW-MOCK"})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if response.Data.FlwRef != secretKey {
+					t.Errorf("Expected secret key '%s' got '%s'", secretKey, response.Data.FlwRef)
+				}
+			}(client, secretKey)
+		}
+	}
+
+	wg.Wait()
+}
+
+// The User-Agent and live base URL should be configurable
+func TestClientUserAgentAndLiveURL(t *testing.T) {
+	t.Parallel()
+
+	server := echoServer()
+	defer server.Close()
+
+	client := NewClient(
+		WithKeys("FLWPUBK-public-X", "FLWSECK-secret-X"),
+		WithBaseURLs(server.URL, "http://127.0.0.1:1"),
+		WithLive(true),
+		WithUserAgent("my-shop/1.0"),
+	)
+
+	response, err := client.CaptureTyped(context.Background(), CaptureRequest{FlwRef: "FLW-MOCK"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, response.Message, "my-shop/1.0")
+	assertEqual(t, response.Data.TxRef, "FLW-MOCK")
+	assertEqual(t, client.GetPublicKey(), "FLWPUBK-public-X")
+}
+
+// Requests should be abandoned when the context's deadline is exceeded
+func TestContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	client := NewClient(WithKeys("FLWPUBK-public-X", "FLWSECK-secret-X"), WithBaseURLs("", server.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetFeesContext(ctx, map[string]interface{}{"amount": "300", "currency": "NGN"})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded got %v", err)
+	}
+	assertEqual(t, IsRetryable(err), false)
+}
+
+// The PIN charge should not be sent if the context is canceled after the first charge
+func TestContextCanceledBeforePinCharge(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	requests := 0
+
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		cancel()
+
+		return respondWith(`{
+			"status": "success", "message": "AUTH_SUGGESTION", "data": {"suggested_auth": "PIN"}
+		}`)(req)
+	})
+
+	client := NewClient(
+		WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"),
+		WithHTTPClient(&http.Client{Transport: transport}),
+	)
+
+	_, err := client.ChargeCardContext(ctx, map[string]interface{}{
+		"cardno": "5438898014560229", "cvv": "789", "expirymonth": "09", "expiryyear": "19",
+		"amount": "300", "email": "user@example.com", "phonenumber": "081245554343",
+		"firstname": "user", "lastname": "example", "IP": "103.238.105.185",
+		"txRef": "MXX-AYT-4578", "redirect_url": "http://127.0.0.1", "pin": "3310",
+	})
+
+	assertEqual(t, err, context.Canceled)
+	assertEqual(t, requests, 1)
+}
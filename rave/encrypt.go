@@ -4,21 +4,27 @@ package rave
 
 import (
 	"bytes"
+	"crypto/cipher"
 	"crypto/des"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
+	"fmt"
 	"strings"
+	"sync"
 )
 
 // getKey : Get a key for encryption
-func (r Rave) getKey(seckey string) string {
+func (r Rave) getKey(seckey string) (string, error) {
 	hashedSeckey := md5.Sum([]byte(seckey))
 	hashedSeckeyLast12 := hashedSeckey[len(hashedSeckey)-6:] // -6 because it's a hex byte array not a string
 	seckeyAdjusted := strings.Replace(seckey, "FLWSECK-", "", 1)
+	if len(seckeyAdjusted) < 12 {
+		return "", ErrInvalidSecretKey
+	}
 	seckeyAdjustedFirst12 := seckeyAdjusted[:12]
 
-	return seckeyAdjustedFirst12 + hex.EncodeToString(hashedSeckeyLast12[:])
+	return seckeyAdjustedFirst12 + hex.EncodeToString(hashedSeckeyLast12[:]), nil
 }
 
 // pkcs5Padding : Implements PKCS5 padding
@@ -28,37 +34,136 @@ func pkcs5Padding(ciphertext []byte, blockSize int) []byte {
 	return append(ciphertext, padtext...)
 }
 
-// Encrypt3Des : Encrypts the data using 3Des encryption
-// Go doesn't include ECB encryption in the standard library for security reasons
-// reference: https://gist.github.com/cuixin/10612934
-func (r Rave) Encrypt3Des(payload string) string {
-	seckey := r.GetSecretKey()
-	encryptedSecretKey := r.getKey(seckey)
+// pkcs5Unpadding : Remove and check PKCS5 padding
+func pkcs5Unpadding(plaintext []byte, blockSize int) ([]byte, error) {
+	if len(plaintext) == 0 {
+		return nil, fmt.Errorf("%w: the payload is empty", ErrInvalidCiphertext)
+	}
 
-	return r.encrypt3Des(encryptedSecretKey, payload)
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > blockSize || !bytes.HasSuffix(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, fmt.Errorf("%w: invalid padding", ErrInvalidCiphertext)
+	}
+
+	return plaintext[:len(plaintext)-padding], nil
 }
 
-// contains the logic for encryption with 3Des
-func (r Rave) encrypt3Des(key string, payload string) string {
-	block, err := des.NewTripleDESCipher([]byte(key))
+// Encrypter : Encrypts and decrypts payloads with the key derived from a secret key.
+// The key is derived once and an Encrypter is safe for concurrent use.
+type Encrypter struct {
+	block cipher.Block
+}
+
+// NewEncrypter : Derive the encryption key from secretKey
+func NewEncrypter(secretKey string) (*Encrypter, error) {
+	key, err := Rave{}.getKey(secretKey)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	bs := block.BlockSize() // block size is 8 by default
-	payloadBytes := pkcs5Padding([]byte(payload), bs)
+	return newEncrypter(key)
+}
 
-	if len(payloadBytes)%bs != 0 {
-		panic("Need a multiple of the blocksize")
+// newEncrypter : Create an Encrypter from a derived key
+func newEncrypter(key string) (*Encrypter, error) {
+	block, err := des.NewTripleDESCipher([]byte(key))
+	if err != nil {
+		return nil, err
 	}
+
+	return &Encrypter{block: block}, nil
+}
+
+// Encrypt : Encrypts the data using 3Des encryption
+// Go doesn't include ECB encryption in the standard library for security reasons
+// reference: https://gist.github.com/cuixin/10612934
+func (e *Encrypter) Encrypt(payload string) string {
+	bs := e.block.BlockSize() // block size is 8 by default
+	payloadBytes := pkcs5Padding([]byte(payload), bs)
 	encrypted := make([]byte, len(payloadBytes))
-	dst := encrypted
 
-	for len(payloadBytes) > 0 {
-		block.Encrypt(dst, payloadBytes[:bs])
-		payloadBytes = payloadBytes[bs:]
-		dst = dst[bs:]
+	for i := 0; i < len(payloadBytes); i += bs {
+		e.block.Encrypt(encrypted[i:i+bs], payloadBytes[i:i+bs])
 	}
 
 	return base64.StdEncoding.EncodeToString(encrypted)
 }
+
+// Decrypt : Decrypts a base64 encoded payload returned by Encrypt
+func (e *Encrypter) Decrypt(payload string) (string, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidCiphertext, err)
+	}
+
+	bs := e.block.BlockSize()
+	if len(encrypted)%bs != 0 {
+		return "", fmt.Errorf("%w: the payload isn't a multiple of the block size", ErrInvalidCiphertext)
+	}
+
+	decrypted := make([]byte, len(encrypted))
+	for i := 0; i < len(encrypted); i += bs {
+		e.block.Decrypt(decrypted[i:i+bs], encrypted[i:i+bs])
+	}
+
+	decrypted, err = pkcs5Unpadding(decrypted, bs)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decrypted), nil
+}
+
+// encrypterCache : Encrypter shared by copies of a client, it's derived again if the secret key changes
+type encrypterCache struct {
+	mutex     sync.Mutex
+	secretKey string
+	encrypter *Encrypter
+}
+
+// Encrypter : Returns the Encrypter for the client's secret key
+func (r Rave) Encrypter() (*Encrypter, error) {
+	seckey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// clients that weren't created with NewClient don't have a cache
+	if r.encrypters == nil {
+		return NewEncrypter(seckey)
+	}
+
+	r.encrypters.mutex.Lock()
+	defer r.encrypters.mutex.Unlock()
+
+	if r.encrypters.encrypter == nil || r.encrypters.secretKey != seckey {
+		encrypter, err := NewEncrypter(seckey)
+		if err != nil {
+			return nil, err
+		}
+
+		r.encrypters.secretKey, r.encrypters.encrypter = seckey, encrypter
+	}
+
+	return r.encrypters.encrypter, nil
+}
+
+// Encrypt3Des : Encrypts the data using 3Des encryption with the client's secret key
+func (r Rave) Encrypt3Des(payload string) (string, error) {
+	encrypter, err := r.Encrypter()
+	if err != nil {
+		return "", err
+	}
+
+	return encrypter.Encrypt(payload), nil
+}
+
+// Decrypt3Des : Decrypts a payload encrypted with the client's secret key (e.g a stored "client" payload)
+func (r Rave) Decrypt3Des(payload string) (string, error) {
+	encrypter, err := r.Encrypter()
+	if err != nil {
+		return "", err
+	}
+
+	return encrypter.Decrypt(payload)
+}
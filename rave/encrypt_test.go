@@ -0,0 +1,97 @@
+// Tests for the 3DES encryption
+
+package rave
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Decrypt3Des should return the payload passed to Encrypt3Des
+func TestDecrypt3Des(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(WithKeys("FLWPUBK-public-X", "FLWSECK-bb971402072265fb156e90a3578fe5e6-X"))
+
+	for _, payload := range []string{"", "12345678", `{"cardno": "5438898014560229", "cvv": "789"}`} {
+		encrypted, err := client.Encrypt3Des(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err := client.Decrypt3Des(encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, decrypted, payload)
+	}
+
+	// from testdata/encryption/vectors.json
+	decrypted, err := client.Decrypt3Des("Lgk7z/IvTT9mx3t9vOzHmg==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, decrypted, "Hello world")
+}
+
+// Payloads that weren't encrypted with the secret key should return ErrInvalidCiphertext
+func TestDecrypt3DesInvalidPayload(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(WithKeys("FLWPUBK-public-X", "FLWSECK-bb971402072265fb156e90a3578fe5e6-X"))
+	other := NewClient(WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"))
+
+	encrypted, err := other.Encrypt3Des("Hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, payload := range []string{"", "not base64", "wNZt70lC", encrypted} {
+		_, err := client.Decrypt3Des(payload)
+		if !errors.Is(err, ErrInvalidCiphertext) {
+			t.Errorf("Expected ErrInvalidCiphertext for %q got %v", payload, err)
+		}
+	}
+
+	_, err = NewEncrypter("FLWSECK-short")
+	assertEqual(t, err, ErrInvalidSecretKey)
+}
+
+// The Encrypter should be derived once per client and shared by goroutines
+func TestEncrypterIsReused(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(WithKeys("FLWPUBK-public-X", "FLWSECK-bb971402072265fb156e90a3578fe5e6-X"))
+
+	encrypter, err := client.Encrypter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			reused, err := client.Encrypter()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if reused != encrypter {
+				t.Error("Expected the Encrypter to be reused")
+			}
+
+			payload := fmt.Sprintf(`{"txRef": "tx-%d"}`, i)
+			decrypted, err := reused.Decrypt(reused.Encrypt(payload))
+			if err != nil || decrypted != payload {
+				t.Errorf("Expected %s got %s (%v)", payload, decrypted, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,80 @@
+/*
+This file contains the functions/methods for card charges encrypted in the browser.
+
+The card details are encrypted on the customer's device with the same 3DES
+scheme as Encrypt3Des (see testdata/encryption for the JavaScript version) and
+sent to Rave as is, so the card number, CVV and PIN never reach your servers.
+*/
+
+package rave
+
+import (
+	"context"
+)
+
+// ChargeEncrypted : Send a card charge that was encrypted in the browser.
+// If Rave asks for the PIN or billing address (Data.SuggestedAuth), the browser
+// must encrypt the charge again with them and "suggested_auth" set.
+func (r Rave) ChargeEncrypted(ctx context.Context, clientPayload string) (*ChargeResponse, error) {
+	response, err := r.chargeEncrypted(ctx, "ChargeEncrypted", clientPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeResponse := &ChargeResponse{}
+	err = decodeResponse(response, chargeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return chargeResponse, nil
+}
+
+// StartEncryptedCharge : Same as StartCharge for a card charge encrypted in the browser.
+// amount is what the customer has to pay, it's used to verify the charge after a redirect.
+func (r Rave) StartEncryptedCharge(ctx context.Context, clientPayload string, amount Money) (*ChargeSession, error) {
+	session := &ChargeSession{
+		Request:   CardChargeRequest{Amount: amount, Currency: amount.Currency},
+		Encrypted: true,
+		client:    &r,
+	}
+
+	return session, session.chargeEncrypted(ctx, "StartEncryptedCharge", clientPayload)
+}
+
+// chargeEncrypted : Wrap the encrypted payload with the public key and send it.
+// The charge isn't retried because it's txRef can't be read.
+func (r Rave) chargeEncrypted(ctx context.Context, methodName, clientPayload string) ([]byte, error) {
+	if clientPayload == "" {
+		return nil, &ParameterError{Parameter: "client", Method: methodName}
+	}
+
+	publicKey, err := r.getPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.charge(ctx, encryptedCharge(publicKey, clientPayload), nil)
+}
+
+// SubmitEncrypted : Charge the card again with a payload encrypted in the browser
+// that contains the PIN or billing address and "suggested_auth" (SuggestedAuth)
+func (s *ChargeSession) SubmitEncrypted(ctx context.Context, clientPayload string) error {
+	step := StepNeedPIN
+	if s.Step == StepNeedBillingAddress {
+		step = StepNeedBillingAddress
+	}
+
+	if err := s.expect(step, "an encrypted charge"); err != nil {
+		return err
+	}
+
+	return s.chargeEncrypted(ctx, "SubmitEncrypted", clientPayload)
+}
+
+// chargeEncrypted : Send the encrypted charge and move to the next step
+func (s *ChargeSession) chargeEncrypted(ctx context.Context, methodName, clientPayload string) error {
+	response, err := s.client.chargeEncrypted(ctx, methodName, clientPayload)
+
+	return s.handleCharge(response, err)
+}
@@ -0,0 +1,160 @@
+// Tests for card charges encrypted in the browser
+
+package rave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// encryptionVector : Key and ciphertext produced by the JavaScript snippet in Rave's documentation
+type encryptionVector struct {
+	SecretKey string `json:"secret_key"`
+	Key       string `json:"key"`
+	Payload   string `json:"payload"`
+	Encrypted string `json:"encrypted"`
+}
+
+// browserEncrypt : Encrypt request the way the browser would
+func browserEncrypt(t *testing.T, client Rave, request interface{}) string {
+	t.Helper()
+
+	chargeJSON, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := client.Encrypt3Des(string(chargeJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return encrypted
+}
+
+// The Go encryption should match the one used in browsers (testdata/encryption/generate.js)
+func TestEncryptionVectors(t *testing.T) {
+	t.Parallel()
+
+	data, err := ioutil.ReadFile("testdata/encryption/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors []encryptionVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Rave{}
+	for _, vector := range vectors {
+		key, err := r.getKey(vector.SecretKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, key, vector.Key)
+
+		encrypter, err := newEncrypter(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, encrypter.Encrypt(vector.Payload), vector.Encrypted)
+	}
+}
+
+// Encrypted charges should be sent as is and go through the PIN and OTP steps
+func TestStartEncryptedCharge(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	request := sessionCharge(ravetest.PinCard)
+	request.Currency = "NGN"
+
+	clientPayload := browserEncrypt(t, client, request)
+	session, err := client.StartEncryptedCharge(ctx, clientPayload, request.Amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepNeedPIN)
+	assertEqual(t, server.Charges()[0]["cardno"], ravetest.PinCard)
+
+	// the PIN has to be encrypted with the card
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err = client.ResumeChargeSession(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Encrypted, true)
+
+	err = session.SubmitPIN(ctx, "3310")
+	if !errors.Is(err, ErrUnexpectedChargeStep) {
+		t.Fatalf("Expected ErrUnexpectedChargeStep got %v", err)
+	}
+
+	request.Pin, request.SuggestedAuth = "3310", session.SuggestedAuth
+	if err := session.SubmitEncrypted(ctx, browserEncrypt(t, client, request)); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepNeedOTP)
+
+	if err := session.SubmitOTP(ctx, ravetest.ValidOTP); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepCompleted)
+
+	err = session.SubmitEncrypted(ctx, clientPayload)
+	if !errors.Is(err, ErrUnexpectedChargeStep) {
+		t.Fatalf("Expected ErrUnexpectedChargeStep got %v", err)
+	}
+}
+
+// 3DSecure charges should be verified with the amount passed to StartEncryptedCharge
+func TestEncryptedChargeRedirect(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	request := sessionCharge(ravetest.ThreeDSecureCard)
+	request.Currency = "NGN"
+
+	session, err := client.StartEncryptedCharge(ctx, browserEncrypt(t, client, request), request.Amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepNeedRedirect)
+
+	httpClient := *server.Client()
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	response, err := httpClient.Get(session.AuthURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if err := session.CheckStatus(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, session.Step, StepCompleted)
+
+	_, err = client.ChargeEncrypted(ctx, "")
+	assertEqual(t, err.Error(), "\"client\" is a required parameter for \"ChargeEncrypted\"")
+}
@@ -0,0 +1,285 @@
+/* This file contains the errors returned by the library */
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ErrMissingPublicKey : Returned when no public key was passed to WithKeys or set in the environment
+var ErrMissingPublicKey = errors.New("You must set the \"RAVE_PUBLICKEY\" environment variable or use WithKeys")
+
+// ErrMissingSecretKey : Returned when no secret key was passed to WithKeys or set in the environment
+var ErrMissingSecretKey = errors.New("You must set the \"RAVE_SECKEY\" environment variable or use WithKeys")
+
+// ErrInvalidSecretKey : Returned when an encryption key can't be derived from the secret key
+var ErrInvalidSecretKey = errors.New("The secret key must contain at least 12 characters after the \"FLWSECK-\" prefix")
+
+// ErrInvalidCiphertext : Returned when a payload can't be decrypted (not base64, truncated or invalid padding)
+var ErrInvalidCiphertext = errors.New("The payload can't be decrypted")
+
+// ErrUnknownMobileMoneyProvider : Returned when a mobile money charge is made with an unsupported provider
+var ErrUnknownMobileMoneyProvider = errors.New("Unknown mobile money provider")
+
+// ErrUnsupportedCurrency : Returned when a charge is made in a currency the payment method doesn't support
+var ErrUnsupportedCurrency = errors.New("Unsupported currency")
+
+// ErrTransactionFailed : Returned when Rave reports a pending transaction as failed
+var ErrTransactionFailed = errors.New("The transaction failed")
+
+// ErrInvalidRoutingNumber : Returned when a US account charge has a routing number that isn't valid
+var ErrInvalidRoutingNumber = errors.New("Invalid routing number")
+
+// ErrInvalidAmount : Returned when a request is made with a negative amount
+var ErrInvalidAmount = errors.New("Invalid amount")
+
+// ErrInvalidPreauthAction : Returned when a preauthorized transaction is refunded or voided with another action
+var ErrInvalidPreauthAction = errors.New("The action must be \"void\" or \"refund\"")
+
+// RequestError : Returned when a request couldn't be sent to Rave or it's response couldn't be read
+type RequestError struct {
+	Method string
+	URL    string
+	Err    error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s %s failed: %s", e.Method, e.URL, e.Err)
+}
+
+// Unwrap : Returns the underlying error
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// EncodingError : Returned when a request payload couldn't be encoded to JSON
+type EncodingError struct {
+	Err error
+}
+
+func (e *EncodingError) Error() string {
+	return fmt.Sprintf("Couldn't encode the request payload: %s", e.Err)
+}
+
+// Unwrap : Returns the underlying error
+func (e *EncodingError) Unwrap() error {
+	return e.Err
+}
+
+// message used when Rave's response isn't valid JSON
+const invalidResponseMessage = "Rave returned an invalid response"
+
+// ParameterError : Returned when a required parameter is missing, no request is made to Rave
+type ParameterError struct {
+	Parameter string
+	Method    string
+}
+
+func (e *ParameterError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("\"%s\" is a required parameter for this method", e.Parameter)
+	}
+
+	return fmt.Sprintf("\"%s\" is a required parameter for \"%s\"", e.Parameter, e.Method)
+}
+
+// BillingAddressError : Returned when Rave asks for the billing address of a card
+// (NOAUTH_INTERNATIONAL or AVS_VBVSECURECODE) and some of it's fields are missing
+type BillingAddressError struct {
+	SuggestedAuth string
+	Missing       []string
+	Method        string
+}
+
+func (e *BillingAddressError) Error() string {
+	missing := make([]string, len(e.Missing))
+	for i, field := range e.Missing {
+		missing[i] = fmt.Sprintf("\"%s\"", field)
+	}
+
+	message := fmt.Sprintf(
+		"Rave requires the billing address of this card (%s), missing %s", e.SuggestedAuth, strings.Join(missing, ", "),
+	)
+	if e.Method != "" {
+		message += fmt.Sprintf(" for \"%s\"", e.Method)
+	}
+
+	return message
+}
+
+// APIError : Returned when Rave responds with an error or a response that isn't valid JSON
+type APIError struct {
+	// HTTP status code of the response
+	HTTPStatusCode int
+
+	// "status" and "message" fields of the response
+	Status  string
+	Message string
+
+	// "data.code" field of the response, empty if Rave didn't return one
+	Code string
+
+	// Raw response body
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s. Status Code: %d", e.Message, e.HTTPStatusCode)
+}
+
+// messages (lowercase) Rave uses when a card or account is declined
+var declinedMessages = []string{
+	"declined", "insufficient", "fraud", "do not honour", "do not honor",
+	"expired card", "restricted card", "lost card", "stolen card", "exceeds",
+}
+
+// messages (lowercase) Rave uses when the keys are invalid
+var authMessages = []string{
+	"invalid public key", "invalid secret key", "invalid seckey", "invalid pbfpubkey",
+	"unauthorized", "invalid merchant",
+}
+
+// messages (lowercase) Rave uses when a charge token is expired or invalid
+var tokenMessages = []string{
+	"token expired", "token has expired", "expired token", "invalid token",
+	"token not found", "token does not exist", "token does not belong",
+}
+
+// messages (lowercase) Rave uses when no transaction matches a reference
+var transactionNotFoundMessages = []string{"no transaction found", "transaction not found"}
+
+// Declined : Reports whether the card or account was declined by the issuer
+func (e *APIError) Declined() bool {
+	return containsAny(e.Message, declinedMessages)
+}
+
+// AuthError : Reports whether the request was rejected because of the public or secret key
+func (e *APIError) AuthError() bool {
+	return e.HTTPStatusCode == 401 || e.HTTPStatusCode == 403 || containsAny(e.Message, authMessages)
+}
+
+// TokenError : Reports whether a tokenized charge failed because the token is expired or invalid
+func (e *APIError) TokenError() bool {
+	return containsAny(e.Message, tokenMessages)
+}
+
+// transactionNotFound : Reports whether Rave doesn't have a transaction with the reference that was sent
+func (e *APIError) transactionNotFound() bool {
+	return e.Code == "NO TX" || containsAny(e.Message, transactionNotFoundMessages)
+}
+
+// Retryable : Reports whether the same request could succeed if it's sent again
+func (e *APIError) Retryable() bool {
+	return e.HTTPStatusCode >= 500 || e.HTTPStatusCode == 408 || e.HTTPStatusCode == 429
+}
+
+// ValidationError : Reports whether Rave rejected the parameters of the request
+func (e *APIError) ValidationError() bool {
+	if e.Declined() || e.AuthError() || e.Retryable() || e.TokenError() {
+		return false
+	}
+
+	return e.HTTPStatusCode == 400 || e.HTTPStatusCode == 422
+}
+
+// IsDeclined : Reports whether err is an *APIError for a declined card or account
+func IsDeclined(err error) bool {
+	apiError := &APIError{}
+
+	return errors.As(err, &apiError) && apiError.Declined()
+}
+
+// IsTokenError : Reports whether err is an *APIError for an expired or invalid charge token.
+// The customer has to be charged with their card again to get a new token.
+func IsTokenError(err error) bool {
+	apiError := &APIError{}
+
+	return errors.As(err, &apiError) && apiError.TokenError()
+}
+
+// IsAuthError : Reports whether err is caused by a missing or invalid public or secret key
+func IsAuthError(err error) bool {
+	if errors.Is(err, ErrMissingPublicKey) || errors.Is(err, ErrMissingSecretKey) {
+		return true
+	}
+
+	apiError := &APIError{}
+
+	return errors.As(err, &apiError) && apiError.AuthError()
+}
+
+// IsRetryable : Reports whether err is a network failure, a timeout or a temporary error from Rave.
+// Requests that were canceled and requests that couldn't be built aren't retryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	requestError := &RequestError{}
+	if errors.As(err, &requestError) {
+		return isNetworkError(requestError.Err)
+	}
+
+	apiError := &APIError{}
+
+	return errors.As(err, &apiError) && apiError.Retryable()
+}
+
+// isNetworkError : Reports whether a request failed on the network or timed out
+func isNetworkError(err error) bool {
+	// *url.Error is a net.Error itself, the error it wraps says what failed
+	urlError := &url.Error{}
+	if errors.As(err, &urlError) {
+		err = urlError.Err
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	// the connection was closed before the whole response was read
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsValidationError : Reports whether err is caused by missing or invalid parameters
+func IsValidationError(err error) bool {
+	parameterError := &ParameterError{}
+	if errors.As(err, &parameterError) {
+		return true
+	}
+
+	billingAddressError := &BillingAddressError{}
+	if errors.As(err, &billingAddressError) {
+		return true
+	}
+
+	if errors.Is(err, ErrUnsupportedCurrency) || errors.Is(err, ErrUnknownMobileMoneyProvider) ||
+		errors.Is(err, ErrInvalidRoutingNumber) || errors.Is(err, ErrInvalidAmount) || errors.Is(err, ErrInvalidPreauthAction) ||
+		errors.Is(err, ErrDuplicateReference) || errors.Is(err, ErrUnknownBank) || errors.Is(err, ErrAccountNotResolved) ||
+		errors.Is(err, ErrAccountNameMismatch) || errors.Is(err, ErrAccountNameUnchecked) {
+		return true
+	}
+
+	apiError := &APIError{}
+
+	return errors.As(err, &apiError) && apiError.ValidationError()
+}
+
+// containsAny : Reports whether the lowercase message contains any of the substrings
+func containsAny(message string, substrings []string) bool {
+	message = strings.ToLower(message)
+	for _, substring := range substrings {
+		if strings.Contains(message, substring) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,740 @@
+// Tests that public methods return errors instead of panicking
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc : http.RoundTripper used to inject failing transports
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// failingReader : io.Reader that always fails
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+// respondWith : Returns a transport that responds with the given body
+func respondWith(body string) roundTripFunc {
+	return func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+	}
+}
+
+// failingTransports : Transports that break at different stages of a request
+var failingTransports = map[string]roundTripFunc{
+	"network error": func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("dial tcp: connection refused")
+	},
+	"body read error": func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(failingReader{})}, nil
+	},
+	"malformed body": respondWith("<html>502 Bad Gateway</html>"),
+}
+
+// customerData : Map with the fields of customer and extra
+func customerData(extra map[string]interface{}) map[string]interface{} {
+	data := map[string]interface{}{
+		"email": "user@example.com", "phonenumber": "0902620185", "firstname": "Temi", "lastname": "Adebayo",
+		"IP": "127.0.0.1",
+	}
+	for key, value := range extra {
+		data[key] = value
+	}
+
+	return data
+}
+
+// cardChargeData : Parameters of a card charge made in publicMethods
+func cardChargeData() map[string]interface{} {
+	return customerData(map[string]interface{}{
+		"cardno": "5438898014560229", "cvv": "789", "expirymonth": "09", "expiryyear": "19", "amount": "300",
+		"txRef": "MXX-AYT-4578", "redirect_url": "http://127.0.0.1", "pin": "3310",
+	})
+}
+
+// cardChargeRequest : Typed version of cardChargeData
+func cardChargeRequest() CardChargeRequest {
+	return CardChargeRequest{
+		Card:     Card{CardNo: "5438898014560229", CVV: "789", ExpiryMonth: "09", ExpiryYear: "19"},
+		Customer: customer, Amount: MustMoney("300", "NGN"), TxRef: "MXX-AYT-4578", RedirectURL: "http://127.0.0.1",
+	}
+}
+
+// accountChargeData : Parameters of an account charge made in publicMethods
+func accountChargeData() map[string]interface{} {
+	return customerData(map[string]interface{}{
+		"accountnumber": "0690000031", "accountbank": "044", "amount": "300", "txRef": "ACB-123", "payment_type": "account",
+	})
+}
+
+// methodChargeData : Parameters of USSD, Mcash, bank transfer and mobile money charges made in publicMethods
+func methodChargeData() map[string]interface{} {
+	return customerData(map[string]interface{}{
+		"accountbank": "058", "amount": "300", "currency": "GHS", "txRef": "MTD-123", "orderRef": "ORD-123",
+		"network": "MTN",
+	})
+}
+
+// verifyData : Parameters of a verification made in publicMethods
+func verifyData() map[string]interface{} {
+	return map[string]interface{}{"flw_ref": "FLW-MOCK", "amount": "300", "currency": "NGN"}
+}
+
+// transferData : Parameters of a transfer made in publicMethods
+func transferData() map[string]interface{} {
+	return map[string]interface{}{
+		"account_bank": "044", "account_number": "0690000040", "amount": "500", "currency": "NGN", "reference": "payout-1",
+	}
+}
+
+// bulkTransferData : Parameters of a bulk transfer made in publicMethods
+func bulkTransferData() map[string]interface{} {
+	return map[string]interface{}{"bulk_data": []interface{}{map[string]interface{}{
+		"Bank": "044", "Account Number": "0690000040", "Amount": "500", "Currency": "NGN", "reference": "payout-1",
+	}}}
+}
+
+// publicMethods : Calls every public method that makes a request with valid parameters
+var publicMethods = map[string]func(Rave) error{
+	"ChargeCard": func(r Rave) error {
+		_, err := r.ChargeCard(cardChargeData())
+		return err
+	},
+	"ChargeCardContext": func(r Rave) error {
+		_, err := r.ChargeCardContext(context.Background(), cardChargeData())
+		return err
+	},
+	"ChargeCardTyped": func(r Rave) error {
+		_, err := r.ChargeCardTyped(context.Background(), cardChargeRequest())
+		return err
+	},
+	"StartCharge": func(r Rave) error {
+		_, err := r.StartCharge(context.Background(), cardChargeRequest())
+		return err
+	},
+	"ChargeEncrypted": func(r Rave) error {
+		_, err := r.ChargeEncrypted(context.Background(), "encrypted-payload")
+		return err
+	},
+	"StartEncryptedCharge": func(r Rave) error {
+		_, err := r.StartEncryptedCharge(context.Background(), "encrypted-payload", MustMoney("300", "NGN"))
+		return err
+	},
+	"ValidateCharge": func(r Rave) error {
+		_, err := r.ValidateCharge(map[string]interface{}{"transaction_reference": "FLW-MOCK", "otp": "12345"})
+		return err
+	},
+	"ValidateChargeContext": func(r Rave) error {
+		_, err := r.ValidateChargeContext(
+			context.Background(), map[string]interface{}{"transaction_reference": "FLW-MOCK", "otp": "12345"},
+		)
+		return err
+	},
+	"ValidateChargeTyped": func(r Rave) error {
+		_, err := r.ValidateChargeTyped(
+			context.Background(), ValidateChargeRequest{TransactionReference: "FLW-MOCK", OTP: "12345"},
+		)
+		return err
+	},
+	"ChargeWithToken": func(r Rave) error {
+		_, err := r.ChargeWithToken(customerData(map[string]interface{}{
+			"token": "flw-t0-mock", "amount": "300", "currency": "NGN", "txRef": "TOK-123",
+		}))
+		return err
+	},
+	"ChargeWithTokenContext": func(r Rave) error {
+		_, err := r.ChargeWithTokenContext(context.Background(), customerData(map[string]interface{}{
+			"token": "flw-t0-mock", "amount": "300", "currency": "NGN", "txRef": "TOK-123",
+		}))
+		return err
+	},
+	"ChargeWithTokenTyped": func(r Rave) error {
+		_, err := r.ChargeWithTokenTyped(context.Background(), TokenChargeRequest{
+			Customer: customer, Token: "flw-t0-mock", Amount: MustMoney("300", "NGN"), TxRef: "TOK-123",
+		})
+		return err
+	},
+	"ChargeAccount": func(r Rave) error {
+		_, err := r.ChargeAccount(accountChargeData())
+		return err
+	},
+	"ChargeAccountContext": func(r Rave) error {
+		_, err := r.ChargeAccountContext(context.Background(), accountChargeData())
+		return err
+	},
+	"ChargeAccountTyped": func(r Rave) error {
+		_, err := r.ChargeAccountTyped(context.Background(), AccountChargeRequest{
+			Customer: customer, AccountNumber: "0690000031", AccountBank: "044", Amount: MustMoney("300", "NGN"),
+			TxRef: "ACB-123", PaymentType: "account",
+		})
+		return err
+	},
+	"ChargeUSAccountTyped": func(r Rave) error {
+		_, err := r.ChargeUSAccountTyped(context.Background(), USAccountChargeRequest{
+			Customer: customer, AccountNumber: "0690000031", RoutingNumber: "021000021", Amount: MustMoney("300", "USD"),
+			TxRef: "ACH-123", RedirectURL: "http://127.0.0.1",
+		})
+		return err
+	},
+	"ChargeSouthAfricanAccountTyped": func(r Rave) error {
+		_, err := r.ChargeSouthAfricanAccountTyped(context.Background(), SouthAfricanAccountChargeRequest{
+			Customer: customer, AccountNumber: "0690000031", AccountBank: "470010", Passcode: "12345",
+			Amount: MustMoney("300", "ZAR"), TxRef: "ZA-123",
+		})
+		return err
+	},
+	"ValidateAccountCharge": func(r Rave) error {
+		_, err := r.ValidateAccountCharge(map[string]interface{}{"transactionreference": "FLW-MOCK", "otp": "12345"})
+		return err
+	},
+	"ValidateAccountChargeContext": func(r Rave) error {
+		_, err := r.ValidateAccountChargeContext(
+			context.Background(), map[string]interface{}{"transactionreference": "FLW-MOCK", "otp": "12345"},
+		)
+		return err
+	},
+	"ValidateAccountChargeTyped": func(r Rave) error {
+		_, err := r.ValidateAccountChargeTyped(
+			context.Background(), ValidateAccountChargeRequest{TransactionReference: "FLW-MOCK", OTP: "12345"},
+		)
+		return err
+	},
+	"ChargeMobileMoney": func(r Rave) error {
+		_, err := r.ChargeMobileMoney(MobileMoneyGhana, methodChargeData())
+		return err
+	},
+	"ChargeMobileMoneyContext": func(r Rave) error {
+		_, err := r.ChargeMobileMoneyContext(context.Background(), MobileMoneyGhana, methodChargeData())
+		return err
+	},
+	"ChargeMobileMoneyTyped": func(r Rave) error {
+		_, err := r.ChargeMobileMoneyTyped(context.Background(), MobileMoneyChargeRequest{
+			Customer: customer, Provider: MobileMoneyGhana, Amount: MustMoney("300", "GHS"), TxRef: "MTD-123",
+			OrderRef: "ORD-123", Network: "MTN",
+		})
+		return err
+	},
+	"ChargeUSSD": func(r Rave) error {
+		_, err := r.ChargeUSSD(methodChargeData())
+		return err
+	},
+	"ChargeUSSDContext": func(r Rave) error {
+		_, err := r.ChargeUSSDContext(context.Background(), methodChargeData())
+		return err
+	},
+	"ChargeUSSDTyped": func(r Rave) error {
+		_, err := r.ChargeUSSDTyped(context.Background(), USSDChargeRequest{
+			Customer: customer, AccountBank: "058", Amount: MustMoney("300", "NGN"), TxRef: "MTD-123", OrderRef: "ORD-123",
+		})
+		return err
+	},
+	"ChargeMcash": func(r Rave) error {
+		_, err := r.ChargeMcash(methodChargeData())
+		return err
+	},
+	"ChargeMcashContext": func(r Rave) error {
+		_, err := r.ChargeMcashContext(context.Background(), methodChargeData())
+		return err
+	},
+	"ChargeMcashTyped": func(r Rave) error {
+		_, err := r.ChargeMcashTyped(context.Background(), McashChargeRequest{
+			Customer: customer, Amount: MustMoney("300", "NGN"), TxRef: "MTD-123", OrderRef: "ORD-123",
+		})
+		return err
+	},
+	"ChargeBankTransfer": func(r Rave) error {
+		_, err := r.ChargeBankTransfer(methodChargeData())
+		return err
+	},
+	"ChargeBankTransferContext": func(r Rave) error {
+		_, err := r.ChargeBankTransferContext(context.Background(), methodChargeData())
+		return err
+	},
+	"ChargeBankTransferTyped": func(r Rave) error {
+		_, err := r.ChargeBankTransferTyped(context.Background(), BankTransferRequest{
+			Customer: customer, Amount: MustMoney("300", "NGN"), TxRef: "MTD-123",
+		})
+		return err
+	},
+	"AwaitBankTransfer": func(r Rave) error {
+		// the account has expired so the transfer is only checked once
+		transfer := &BankTransferResponse{Data: BankTransferData{
+			FlwRef: "FLW-MOCK", Amount: MustMoney("300", "NGN"), ExpiresAt: time.Now().Add(-time.Minute),
+		}}
+		_, err := r.AwaitBankTransfer(context.Background(), transfer, MustMoney("300", "NGN"), time.Millisecond)
+		return err
+	},
+	"VerifyTransaction": func(r Rave) error {
+		_, err := r.VerifyTransaction(verifyData())
+		return err
+	},
+	"VerifyTransactionContext": func(r Rave) error {
+		_, err := r.VerifyTransactionContext(context.Background(), verifyData())
+		return err
+	},
+	"VerifyTransactionTyped": func(r Rave) error {
+		_, err := r.VerifyTransactionTyped(context.Background(), VerifyRequest{FlwRef: "FLW-MOCK", Amount: MustMoney("300", "NGN")})
+		return err
+	},
+	"XrequeryTransactionVerification": func(r Rave) error {
+		_, err := r.XrequeryTransactionVerification(verifyData())
+		return err
+	},
+	"XrequeryTransactionVerificationContext": func(r Rave) error {
+		_, err := r.XrequeryTransactionVerificationContext(context.Background(), verifyData())
+		return err
+	},
+	"XrequeryTransactionVerificationTyped": func(r Rave) error {
+		_, err := r.XrequeryTransactionVerificationTyped(context.Background(), VerifyRequest{FlwRef: "FLW-MOCK", Amount: MustMoney("300", "NGN"), Currency: "NGN"})
+		return err
+	},
+	"VerifyRedirect": func(r Rave) error {
+		query := url.Values{"response": {`{"flwRef": "FLW-MOCK", "txRef": "MXX-AYT-4578"}`}}
+		_, err := r.VerifyRedirect(context.Background(), query, "MXX-AYT-4578", MustMoney("300", "NGN"))
+		return err
+	},
+	"RefundTransaction": func(r Rave) error {
+		_, err := r.RefundTransaction(map[string]interface{}{"ref": "FLW-MOCK"})
+		return err
+	},
+	"RefundTransactionContext": func(r Rave) error {
+		_, err := r.RefundTransactionContext(context.Background(), map[string]interface{}{"ref": "FLW-MOCK"})
+		return err
+	},
+	"RefundTransactionTyped": func(r Rave) error {
+		_, err := r.RefundTransactionTyped(context.Background(), RefundRequest{Ref: "FLW-MOCK"})
+		return err
+	},
+	"PreauthorizeCard": func(r Rave) error {
+		_, err := r.PreauthorizeCard(cardChargeData())
+		return err
+	},
+	"PreauthorizeCardContext": func(r Rave) error {
+		_, err := r.PreauthorizeCardContext(context.Background(), cardChargeData())
+		return err
+	},
+	"PreauthorizeCardTyped": func(r Rave) error {
+		_, err := r.PreauthorizeCardTyped(context.Background(), cardChargeRequest())
+		return err
+	},
+	"Capture": func(r Rave) error {
+		_, err := r.Capture(map[string]interface{}{"flwRef": "FLW-MOCK"})
+		return err
+	},
+	"CaptureContext": func(r Rave) error {
+		_, err := r.CaptureContext(context.Background(), map[string]interface{}{"flwRef": "FLW-MOCK"})
+		return err
+	},
+	"CaptureTyped": func(r Rave) error {
+		_, err := r.CaptureTyped(context.Background(), CaptureRequest{FlwRef: "FLW-MOCK"})
+		return err
+	},
+	"RefundOrVoidPreauth": func(r Rave) error {
+		_, err := r.RefundOrVoidPreauth(map[string]interface{}{"ref": "FLW-MOCK", "action": "void"})
+		return err
+	},
+	"RefundOrVoidPreauthContext": func(r Rave) error {
+		_, err := r.RefundOrVoidPreauthContext(context.Background(), map[string]interface{}{"ref": "FLW-MOCK", "action": "refund"})
+		return err
+	},
+	"RefundOrVoidPreauthTyped": func(r Rave) error {
+		_, err := r.RefundOrVoidPreauthTyped(context.Background(), RefundOrVoidRequest{Ref: "FLW-MOCK", Action: "void"})
+		return err
+	},
+	"VoidPreauth": func(r Rave) error {
+		_, err := r.VoidPreauth(context.Background(), "FLW-MOCK")
+		return err
+	},
+	"RefundPreauth": func(r Rave) error {
+		_, err := r.RefundPreauth(context.Background(), "FLW-MOCK")
+		return err
+	},
+	"GetFees": func(r Rave) error {
+		_, err := r.GetFees(map[string]interface{}{"amount": "300", "currency": "NGN"})
+		return err
+	},
+	"GetFeesContext": func(r Rave) error {
+		_, err := r.GetFeesContext(context.Background(), map[string]interface{}{"amount": "300", "currency": "NGN"})
+		return err
+	},
+	"GetFeesTyped": func(r Rave) error {
+		_, err := r.GetFeesTyped(context.Background(), FeeRequest{Amount: MustMoney("300", "NGN")})
+		return err
+	},
+	"ListBanks": func(r Rave) error {
+		_, err := r.ListBanks()
+		return err
+	},
+	"ListBanksContext": func(r Rave) error {
+		_, err := r.ListBanksContext(context.Background())
+		return err
+	},
+	"ListBanksTyped": func(r Rave) error {
+		_, err := r.ListBanksTyped(context.Background())
+		return err
+	},
+	"CachedBanks": func(r Rave) error {
+		_, err := r.CachedBanks(context.Background())
+		return err
+	},
+	"InitiateTransfer": func(r Rave) error {
+		_, err := r.InitiateTransfer(transferData())
+		return err
+	},
+	"InitiateTransferContext": func(r Rave) error {
+		_, err := r.InitiateTransferContext(context.Background(), transferData())
+		return err
+	},
+	"InitiateTransferTyped": func(r Rave) error {
+		_, err := r.InitiateTransferTyped(context.Background(), TransferRequest{
+			AccountBank: "044", AccountNumber: "0690000040", Amount: MustMoney("500", "NGN"), Reference: "payout-1",
+		})
+		return err
+	},
+	"InitiateBulkTransfer": func(r Rave) error {
+		_, err := r.InitiateBulkTransfer(bulkTransferData())
+		return err
+	},
+	"InitiateBulkTransferContext": func(r Rave) error {
+		_, err := r.InitiateBulkTransferContext(context.Background(), bulkTransferData())
+		return err
+	},
+	"InitiateBulkTransferTyped": func(r Rave) error {
+		_, err := r.InitiateBulkTransferTyped(context.Background(), BulkTransferRequest{Transfers: []BulkTransferItem{{
+			Bank: "044", AccountNumber: "0690000040", Amount: MustMoney("500", "NGN"), Reference: "payout-1",
+		}}})
+		return err
+	},
+	"GetTransfer": func(r Rave) error {
+		_, err := r.GetTransfer("payout-1")
+		return err
+	},
+	"GetTransferContext": func(r Rave) error {
+		_, err := r.GetTransferContext(context.Background(), "payout-1")
+		return err
+	},
+	"GetTransferTyped": func(r Rave) error {
+		_, err := r.GetTransferTyped(context.Background(), "payout-1")
+		return err
+	},
+	"ListTransfers": func(r Rave) error {
+		_, err := r.ListTransfers(map[string]interface{}{"page": 1})
+		return err
+	},
+	"ListTransfersContext": func(r Rave) error {
+		_, err := r.ListTransfersContext(context.Background(), map[string]interface{}{"status": "successful"})
+		return err
+	},
+	"ListTransfersTyped": func(r Rave) error {
+		_, err := r.ListTransfersTyped(context.Background(), ListTransfersRequest{Page: 1})
+		return err
+	},
+	"GetTransferFee": func(r Rave) error {
+		_, err := r.GetTransferFee(map[string]interface{}{"currency": "NGN"})
+		return err
+	},
+	"GetTransferFeeContext": func(r Rave) error {
+		_, err := r.GetTransferFeeContext(context.Background(), map[string]interface{}{"currency": "NGN"})
+		return err
+	},
+	"GetTransferFeeTyped": func(r Rave) error {
+		_, err := r.GetTransferFeeTyped(context.Background(), TransferFeeRequest{Currency: "NGN"})
+		return err
+	},
+	"GetBalance": func(r Rave) error {
+		_, err := r.GetBalance(map[string]interface{}{"currency": "NGN"})
+		return err
+	},
+	"GetBalanceContext": func(r Rave) error {
+		_, err := r.GetBalanceContext(context.Background(), map[string]interface{}{"currency": "NGN"})
+		return err
+	},
+	"GetBalanceTyped": func(r Rave) error {
+		_, err := r.GetBalanceTyped(context.Background(), BalanceRequest{Currency: "NGN"})
+		return err
+	},
+	"ResolveAccount": func(r Rave) error {
+		_, err := r.ResolveAccount(context.Background(), "0690000040", "044")
+		return err
+	},
+	"ResolveAccountContext": func(r Rave) error {
+		_, err := r.ResolveAccountContext(
+			context.Background(), map[string]interface{}{"recipientaccount": "0690000040", "destbankcode": "044"},
+		)
+		return err
+	},
+	"ResolveAccountTyped": func(r Rave) error {
+		_, err := r.ResolveAccountTyped(context.Background(), ResolveAccountRequest{AccountNumber: "0690000040", BankCode: "044"})
+		return err
+	},
+	"CreateBeneficiary": func(r Rave) error {
+		_, err := r.CreateBeneficiary(map[string]interface{}{"account_number": "0690000040", "account_bank": "044"})
+		return err
+	},
+	"CreateBeneficiaryContext": func(r Rave) error {
+		_, err := r.CreateBeneficiaryContext(
+			context.Background(), map[string]interface{}{"account_number": "0690000040", "account_bank": "044"},
+		)
+		return err
+	},
+	"CreateBeneficiaryTyped": func(r Rave) error {
+		_, err := r.CreateBeneficiaryTyped(context.Background(), BeneficiaryRequest{AccountNumber: "0690000040", AccountBank: "044"})
+		return err
+	},
+	"ListBeneficiaries": func(r Rave) error {
+		_, err := r.ListBeneficiaries(map[string]interface{}{"page": 1})
+		return err
+	},
+	"ListBeneficiariesContext": func(r Rave) error {
+		_, err := r.ListBeneficiariesContext(context.Background(), map[string]interface{}{})
+		return err
+	},
+	"ListBeneficiariesTyped": func(r Rave) error {
+		_, err := r.ListBeneficiariesTyped(context.Background(), ListBeneficiariesRequest{})
+		return err
+	},
+	"DeleteBeneficiary": func(r Rave) error {
+		_, err := r.DeleteBeneficiary(map[string]interface{}{"id": 1})
+		return err
+	},
+	"DeleteBeneficiaryContext": func(r Rave) error {
+		_, err := r.DeleteBeneficiaryContext(context.Background(), map[string]interface{}{"id": 1})
+		return err
+	},
+	"DeleteBeneficiaryTyped": func(r Rave) error {
+		_, err := r.DeleteBeneficiaryTyped(context.Background(), 1)
+		return err
+	},
+}
+
+// offlineMethods : Public methods of Rave that don't make a request
+var offlineMethods = map[string]bool{
+	"CalculateIntegrityCheckSum": true, "Decrypt3Des": true, "Encrypt3Des": true, "Encrypter": true,
+	"GetPublicKey": true, "GetSecretKey": true, "ResumeChargeSession": true,
+}
+
+// Every public method that makes a request should be in publicMethods
+func TestPublicMethodsCovered(t *testing.T) {
+	t.Parallel()
+
+	raveType := reflect.TypeOf(Rave{})
+	for i := 0; i < raveType.NumMethod(); i++ {
+		name := raveType.Method(i).Name
+		if _, ok := publicMethods[name]; !ok && !offlineMethods[name] {
+			t.Errorf("%s isn't called by publicMethods", name)
+		}
+	}
+}
+
+// No public method should panic or exit when the transport fails
+func TestFailingTransports(t *testing.T) {
+	t.Parallel()
+
+	for transportName, transport := range failingTransports {
+		client := NewClient(
+			WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"),
+			WithHTTPClient(&http.Client{Transport: transport}),
+			WithRetryPolicy(NoRetries),
+		)
+
+		for methodName, method := range publicMethods {
+			err := callWithoutPanic(t, method, client)
+			if err == nil {
+				t.Errorf("%s didn't return an error for a transport with a %s", methodName, transportName)
+			}
+		}
+	}
+}
+
+// Successful responses without any data should not cause a panic
+func TestResponsesWithoutData(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"),
+		WithHTTPClient(&http.Client{Transport: respondWith(`{"status": "success"}`)}),
+	)
+
+	for _, method := range publicMethods {
+		callWithoutPanic(t, method, client)
+	}
+}
+
+// Network errors should be returned as *RequestError
+func TestRequestError(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"),
+		WithHTTPClient(&http.Client{Transport: failingTransports["network error"]}),
+		WithRetryPolicy(NoRetries),
+	)
+
+	_, err := client.GetFees(map[string]interface{}{"amount": "300", "currency": "NGN"})
+
+	requestError := &RequestError{}
+	if !errors.As(err, &requestError) {
+		t.Fatalf("Expected a *RequestError got %T", err)
+	}
+	assertEqual(t, requestError.Method, "POST")
+}
+
+// Only network failures and timeouts should be retryable, not requests that couldn't be built
+func TestIsRetryableRequestErrors(t *testing.T) {
+	t.Parallel()
+
+	transports := map[string]struct {
+		transport roundTripFunc
+		retryable bool
+	}{
+		"refused": {func(*http.Request) (*http.Response, error) {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		}, true},
+		"timeout": {func(*http.Request) (*http.Response, error) {
+			return nil, context.DeadlineExceeded
+		}, true},
+		"closed": {func(*http.Request) (*http.Response, error) {
+			return nil, io.EOF
+		}, true},
+		"rejected": {func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("unsupported protocol scheme")
+		}, false},
+	}
+
+	for name, testCase := range transports {
+		client := NewClient(
+			WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"),
+			WithHTTPClient(&http.Client{Transport: testCase.transport}),
+			WithRetryPolicy(NoRetries),
+		)
+
+		_, err := client.GetFees(map[string]interface{}{"amount": "300", "currency": "NGN"})
+		if IsRetryable(err) != testCase.retryable {
+			t.Errorf("%s: expected IsRetryable to be %v for %v", name, testCase.retryable, err)
+		}
+	}
+
+	client := NewClient(
+		WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"),
+		WithBaseURLs("", "http://rave test"),
+		WithRetryPolicy(NoRetries),
+	)
+
+	_, err := client.GetFees(map[string]interface{}{"amount": "300", "currency": "NGN"})
+
+	requestError := &RequestError{}
+	if !errors.As(err, &requestError) {
+		t.Fatalf("Expected a *RequestError got %T", err)
+	}
+	assertEqual(t, IsRetryable(err), false)
+}
+
+// Secret keys that are too short should return ErrInvalidSecretKey
+func TestEncryptionWithShortSecretKey(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(WithKeys("FLWPUBK-public-X", "FLWSECK-short"))
+
+	_, err := client.Encrypt3Des("Hello world")
+
+	assertEqual(t, err, ErrInvalidSecretKey)
+}
+
+// callWithoutPanic : Fails the test if method panics
+func callWithoutPanic(t *testing.T, method func(Rave) error, client Rave) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			t.Errorf("Method panicked: %v", recovered)
+		}
+	}()
+
+	return method(client)
+}
+
+// respondWithStatus : Returns a transport that responds with the given status code and body
+func respondWithStatus(statusCode int, body string) roundTripFunc {
+	return func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: statusCode, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+	}
+}
+
+// Errors returned by Rave should be classified from the HTTP status and response body
+func TestAPIErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		statusCode                            int
+		body                                  string
+		message, code                         string
+		declined, auth, retryable, validation bool
+	}{
+		{
+			400, `{"status": "error", "message": "Fraudulent. Transaction", "data": {"code": "FLW_ERR"}}`,
+			"Fraudulent. Transaction", "FLW_ERR", true, false, false, false,
+		},
+		{
+			401, `{"status": "error", "message": "Invalid secret key passed"}`,
+			"Invalid secret key passed", "", false, true, false, false,
+		},
+		{
+			400, `{"status": "error", "message": "Invalid card number", "data": {"code": "ERR"}}`,
+			"Invalid card number", "ERR", false, false, false, true,
+		},
+		{
+			502, `<html>502 Bad Gateway</html>`,
+			invalidResponseMessage, "", false, false, true, false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		client := NewClient(
+			WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"),
+			WithHTTPClient(&http.Client{Transport: respondWithStatus(testCase.statusCode, testCase.body)}),
+			WithRetryPolicy(NoRetries),
+		)
+
+		_, err := client.Capture(map[string]interface{}{"flwRef": "FLW-MOCK"})
+
+		apiError := &APIError{}
+		if !errors.As(err, &apiError) {
+			t.Fatalf("Expected an *APIError got %T", err)
+		}
+
+		assertEqual(t, apiError.HTTPStatusCode, testCase.statusCode)
+		assertEqual(t, apiError.Message, testCase.message)
+		assertEqual(t, apiError.Code, testCase.code)
+		assertEqual(t, string(apiError.Body), testCase.body)
+		assertEqual(t, IsDeclined(err), testCase.declined)
+		assertEqual(t, IsAuthError(err), testCase.auth)
+		assertEqual(t, IsRetryable(err), testCase.retryable)
+		assertEqual(t, IsValidationError(err), testCase.validation)
+	}
+}
+
+// Missing parameters should be reported as validation errors
+func TestParameterErrorIsValidationError(t *testing.T) {
+	t.Parallel()
+
+	_, err := rave.Capture(map[string]interface{}{})
+
+	assertEqual(t, err.Error(), "\"flwRef\" is a required parameter for \"Capture\"")
+	assertEqual(t, IsValidationError(err), true)
+	assertEqual(t, IsRetryable(err), false)
+}
@@ -0,0 +1,311 @@
+/*
+This file contains HoldManager, which keeps track of the amounts held on cards
+by preauthorized charges and voids the ones that are never captured.
+
+	holds := rave.NewHoldManager(client, 72*time.Hour)
+	go holds.RunSweeper(ctx, time.Hour)
+
+	hold, _, err := holds.Preauthorize(ctx, request)
+	...
+	_, err = holds.Capture(ctx, hold.FlwRef, rave.Money{})
+*/
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HoldState : Whether a hold is still open, captured or voided
+type HoldState string
+
+// States of a hold
+const (
+	HoldOpen     HoldState = "open"
+	HoldCaptured HoldState = "captured"
+	HoldVoided   HoldState = "voided"
+)
+
+// DefaultHoldSweepInterval : How often RunSweeper looks for expired holds when no interval is given
+const DefaultHoldSweepInterval = time.Hour
+
+// ErrUnknownHold : Returned when an operation is made on a hold the manager doesn't track
+var ErrUnknownHold = errors.New("Unknown hold")
+
+// ErrHoldNotOpen : Returned when a hold that was already captured or voided is captured or voided
+var ErrHoldNotOpen = errors.New("The hold isn't open")
+
+// ErrHoldBusy : Returned when the hold is being captured or voided by another goroutine (or the sweeper)
+var ErrHoldBusy = errors.New("The hold is being captured or voided")
+
+// ErrHoldOpen : Returned by Forget when the hold wasn't captured or voided yet
+var ErrHoldOpen = errors.New("The hold is still open")
+
+// ErrHoldExists : Returned by Track when the manager already tracks a hold with the same flwRef
+var ErrHoldExists = errors.New("The hold is already tracked")
+
+// Hold : An amount held on a card by a preauthorized charge
+type Hold struct {
+	FlwRef    string
+	TxRef     string
+	Amount    Money
+	State     HoldState
+	CreatedAt time.Time
+
+	// The hold is voided by the sweeper after this time if it isn't captured
+	ExpiresAt time.Time
+
+	// Set once the hold is captured, it's less than Amount after a partial capture
+	CapturedAmount Money
+
+	busy bool
+}
+
+// HoldManager : Records preauthorized holds and captures or voids them. It's safe for concurrent use.
+type HoldManager struct {
+	// OnSweepError is called when the sweeper can't void an expired hold, it's retried on the next sweep
+	OnSweepError func(hold Hold, err error)
+
+	client Rave
+	maxAge time.Duration
+	now    func() time.Time
+
+	mutex sync.Mutex
+	holds map[string]*Hold
+}
+
+// NewHoldManager : Create a manager whose holds are voided when they're older than maxAge
+func NewHoldManager(client Rave, maxAge time.Duration) *HoldManager {
+	return &HoldManager{client: client, maxAge: maxAge, now: time.Now, holds: map[string]*Hold{}}
+}
+
+// Preauthorize : Preauthorize the card with PreauthorizeCardTyped and record the hold.
+// The hold is nil if the charge still needs a PIN, OTP or redirect, complete it
+// (e.g with a ChargeSession) then record it with Track.
+func (m *HoldManager) Preauthorize(ctx context.Context, request CardChargeRequest) (*Hold, *ChargeResponse, error) {
+	response, err := m.client.PreauthorizeCardTyped(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if code := response.Data.ChargeResponseCode; code != "00" && code != "0" {
+		return nil, response, nil
+	}
+
+	amount := response.Data.Amount
+	if amount.IsZero() {
+		amount = request.Amount
+	}
+
+	hold, err := m.Track(response.Data.FlwRef, response.Data.TxRef, amount, m.now())
+	if err != nil {
+		return nil, response, err
+	}
+
+	return &hold, response, nil
+}
+
+// Track : Record a hold that was authorized outside the manager (or restore one after a restart).
+// It expires maxAge after authorizedAt, the current time is used if authorizedAt is zero.
+func (m *HoldManager) Track(flwRef, txRef string, amount Money, authorizedAt time.Time) (Hold, error) {
+	if authorizedAt.IsZero() {
+		authorizedAt = m.now()
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.holds[flwRef]; ok {
+		return Hold{}, fmt.Errorf("%w: \"%s\"", ErrHoldExists, flwRef)
+	}
+
+	hold := &Hold{
+		FlwRef: flwRef, TxRef: txRef, Amount: amount, State: HoldOpen,
+		CreatedAt: authorizedAt, ExpiresAt: authorizedAt.Add(m.maxAge),
+	}
+	m.holds[flwRef] = hold
+
+	return *hold, nil
+}
+
+// Forget : Stop tracking a hold that was captured or voided
+func (m *HoldManager) Forget(flwRef string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	hold, ok := m.holds[flwRef]
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: \"%s\"", ErrUnknownHold, flwRef)
+	case hold.busy:
+		return ErrHoldBusy
+	case hold.State == HoldOpen:
+		return fmt.Errorf("%w: \"%s\"", ErrHoldOpen, flwRef)
+	}
+
+	delete(m.holds, flwRef)
+
+	return nil
+}
+
+// Hold : Returns the hold with flwRef
+func (m *HoldManager) Hold(flwRef string) (Hold, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	hold, ok := m.holds[flwRef]
+	if !ok {
+		return Hold{}, false
+	}
+
+	return *hold, true
+}
+
+// Holds : Returns every hold, including the captured and voided ones that weren't forgotten.
+// Sweep forgets closed holds once they're maxAge past their expiry.
+func (m *HoldManager) Holds() []Hold {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	holds := make([]Hold, 0, len(m.holds))
+	for _, hold := range m.holds {
+		holds = append(holds, *hold)
+	}
+
+	return holds
+}
+
+// Capture : Capture an open hold with CaptureTyped, leave amount unset to capture the whole hold
+func (m *HoldManager) Capture(ctx context.Context, flwRef string, amount Money) (*CaptureResponse, error) {
+	if err := m.acquire(flwRef); err != nil {
+		return nil, err
+	}
+
+	response, err := m.client.CaptureTyped(ctx, CaptureRequest{FlwRef: flwRef, Amount: amount})
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	hold := m.holds[flwRef]
+	hold.busy = false
+	if err != nil {
+		return nil, err
+	}
+
+	hold.State = HoldCaptured
+	hold.CapturedAmount = response.CapturedAmount
+	if hold.CapturedAmount.IsZero() {
+		hold.CapturedAmount = firstNonZero(amount, hold.Amount)
+	}
+
+	return response, nil
+}
+
+// Void : Release an open hold with RefundOrVoidPreauth
+func (m *HoldManager) Void(ctx context.Context, flwRef string) (*RefundOrVoidResponse, error) {
+	if err := m.acquire(flwRef); err != nil {
+		return nil, err
+	}
+
+	response, err := m.client.VoidPreauth(ctx, flwRef)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	hold := m.holds[flwRef]
+	hold.busy = false
+	if err != nil {
+		return nil, err
+	}
+
+	hold.State = HoldVoided
+
+	return response, nil
+}
+
+// Sweep : Void the open holds that expired, the holds that were voided are returned.
+// Holds that couldn't be voided are left open and reported to OnSweepError, captured
+// and voided holds are forgotten once they're maxAge past their expiry.
+func (m *HoldManager) Sweep(ctx context.Context) []Hold {
+	now := m.now()
+
+	var expired []string
+	m.mutex.Lock()
+	for flwRef, hold := range m.holds {
+		switch {
+		case hold.busy:
+		case hold.State == HoldOpen && !now.Before(hold.ExpiresAt):
+			expired = append(expired, flwRef)
+		case hold.State != HoldOpen && now.After(hold.ExpiresAt.Add(m.maxAge)):
+			// closed holds are kept for a while so their state can still be checked
+			delete(m.holds, flwRef)
+		}
+	}
+	m.mutex.Unlock()
+
+	var voided []Hold
+	for _, flwRef := range expired {
+		_, err := m.Void(ctx, flwRef)
+
+		// the hold was captured or voided since it was found
+		if errors.Is(err, ErrHoldNotOpen) || errors.Is(err, ErrHoldBusy) {
+			continue
+		}
+
+		hold, _ := m.Hold(flwRef)
+		if err != nil {
+			if m.OnSweepError != nil {
+				m.OnSweepError(hold, err)
+			}
+			continue
+		}
+
+		voided = append(voided, hold)
+	}
+
+	return voided
+}
+
+// RunSweeper : Call Sweep every interval (DefaultHoldSweepInterval if it's 0) until ctx is done
+func (m *HoldManager) RunSweeper(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultHoldSweepInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.Sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// acquire : Mark an open hold as busy so it isn't captured and voided at the same time
+func (m *HoldManager) acquire(flwRef string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	hold, ok := m.holds[flwRef]
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: \"%s\"", ErrUnknownHold, flwRef)
+	case hold.busy:
+		return ErrHoldBusy
+	case hold.State != HoldOpen:
+		return fmt.Errorf("%w: it was %s", ErrHoldNotOpen, hold.State)
+	}
+
+	hold.busy = true
+
+	return nil
+}
@@ -0,0 +1,234 @@
+// Tests for the preauthorization hold manager
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// holdRequest : Returns a ₦1052.50 preauthorization on a card that doesn't need authorization
+func holdRequest(txRef string) CardChargeRequest {
+	request := sessionCharge(ravetest.NoAuthCard)
+	request.TxRef = txRef
+
+	return request
+}
+
+// Holds can only be captured or voided once
+func TestHoldManagerStateChecks(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	holds := NewHoldManager(newSessionClient(server), 72*time.Hour)
+	ctx := context.Background()
+
+	hold, _, err := holds.Preauthorize(ctx, holdRequest("hold-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, hold.State, HoldOpen)
+	assertEqual(t, hold.Amount, MustMoney("1052.50", "NGN"))
+	assertEqual(t, hold.ExpiresAt, hold.CreatedAt.Add(72*time.Hour))
+
+	if _, err := holds.Capture(ctx, hold.FlwRef, MustMoney("1000", "NGN")); err != nil {
+		t.Fatal(err)
+	}
+
+	captured, _ := holds.Hold(hold.FlwRef)
+	assertEqual(t, captured.State, HoldCaptured)
+	assertEqual(t, captured.CapturedAmount, MustMoney("1000", "NGN"))
+
+	if _, err := holds.Capture(ctx, hold.FlwRef, Money{}); !errors.Is(err, ErrHoldNotOpen) {
+		t.Errorf("Expected ErrHoldNotOpen got %v", err)
+	}
+
+	if _, err := holds.Void(ctx, hold.FlwRef); !errors.Is(err, ErrHoldNotOpen) {
+		t.Errorf("Expected ErrHoldNotOpen got %v", err)
+	}
+
+	voided, _, err := holds.Preauthorize(ctx, holdRequest("hold-2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := holds.Void(ctx, voided.FlwRef); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := holds.Capture(ctx, voided.FlwRef, Money{}); !errors.Is(err, ErrHoldNotOpen) {
+		t.Errorf("Expected ErrHoldNotOpen got %v", err)
+	}
+
+	if _, err := holds.Capture(ctx, "FLW-UNKNOWN", Money{}); !errors.Is(err, ErrUnknownHold) {
+		t.Errorf("Expected ErrUnknownHold got %v", err)
+	}
+
+	assertEqual(t, server.Requests(ravetest.CapturePath), 1)
+	assertEqual(t, len(holds.Holds()), 2)
+}
+
+// Sweep should only void the open holds that expired
+func TestHoldManagerSweep(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	holds := NewHoldManager(newSessionClient(server), time.Hour)
+	ctx := context.Background()
+
+	var failed []Hold
+	holds.OnSweepError = func(hold Hold, err error) { failed = append(failed, hold) }
+
+	stale, _, err := holds.Preauthorize(ctx, holdRequest("hold-stale"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	captured, _, err := holds.Preauthorize(ctx, holdRequest("hold-captured"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := holds.Capture(ctx, captured.FlwRef, Money{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a hold Rave doesn't know about can't be voided
+	unknown, err := holds.Track("FLW-UNKNOWN", "hold-unknown", MustMoney("500", "NGN"), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, len(holds.Sweep(ctx)), 0)
+
+	// an hour and a half later a new hold is made
+	start := time.Now()
+	holds.now = func() time.Time { return start.Add(90 * time.Minute) }
+
+	recent, _, err := holds.Preauthorize(ctx, holdRequest("hold-recent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	voided := holds.Sweep(ctx)
+	if len(voided) != 1 || voided[0].FlwRef != stale.FlwRef || voided[0].State != HoldVoided {
+		t.Fatalf("Expected %s to be voided got %+v", stale.FlwRef, voided)
+	}
+
+	transaction, _ := server.Transaction(stale.FlwRef)
+	assertEqual(t, transaction.Status, ravetest.StatusVoided)
+
+	for flwRef, state := range map[string]HoldState{captured.FlwRef: HoldCaptured, recent.FlwRef: HoldOpen, unknown.FlwRef: HoldOpen} {
+		hold, _ := holds.Hold(flwRef)
+		assertEqual(t, hold.State, state)
+	}
+
+	if len(failed) != 1 || failed[0].FlwRef != unknown.FlwRef {
+		t.Errorf("Expected the sweeper to fail to void %s got %+v", unknown.FlwRef, failed)
+	}
+}
+
+// Restored holds should expire from their authorization time and closed holds should be forgotten
+func TestHoldManagerTrack(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	holds := NewHoldManager(newSessionClient(server), time.Hour)
+	ctx := context.Background()
+
+	open, _, err := holds.Preauthorize(ctx, holdRequest("hold-restored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a hold authorized before a restart keeps it's original expiry
+	restarted := NewHoldManager(newSessionClient(server), time.Hour)
+	authorizedAt := time.Now().Add(-2 * time.Hour)
+	restored, err := restarted.Track(open.FlwRef, open.TxRef, open.Amount, authorizedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, restored.ExpiresAt, authorizedAt.Add(time.Hour))
+
+	if _, err := restarted.Track(open.FlwRef, open.TxRef, open.Amount, time.Time{}); !errors.Is(err, ErrHoldExists) {
+		t.Errorf("Expected ErrHoldExists got %v", err)
+	}
+
+	if err := restarted.Forget(open.FlwRef); !errors.Is(err, ErrHoldOpen) {
+		t.Errorf("Expected ErrHoldOpen got %v", err)
+	}
+
+	voided := restarted.Sweep(ctx)
+	if len(voided) != 1 || voided[0].FlwRef != open.FlwRef {
+		t.Fatalf("Expected %s to be voided got %+v", open.FlwRef, voided)
+	}
+
+	// voided holds are kept until they're maxAge past their expiry
+	restarted.now = func() time.Time { return authorizedAt.Add(2*time.Hour + time.Minute) }
+	restarted.Sweep(ctx)
+	assertEqual(t, len(restarted.Holds()), 0)
+
+	captured, _, err := holds.Preauthorize(ctx, holdRequest("hold-forgotten"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := holds.Capture(ctx, captured.FlwRef, Money{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := holds.Forget(captured.FlwRef); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := holds.Hold(captured.FlwRef); ok {
+		t.Errorf("Expected %s to be forgotten", captured.FlwRef)
+	}
+}
+
+// RunSweeper should void expired holds until ctx is done
+func TestHoldManagerRunSweeper(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	holds := NewHoldManager(newSessionClient(server), 30*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	hold, _, err := holds.Preauthorize(ctx, holdRequest("hold-sweeper"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- holds.RunSweeper(ctx, 10*time.Millisecond) }()
+
+	for {
+		if swept, _ := holds.Hold(hold.FlwRef); swept.State == HoldVoided {
+			break
+		}
+
+		select {
+		case err := <-done:
+			t.Fatalf("The sweeper stopped before voiding the hold: %v", err)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled got %v", err)
+	}
+}
@@ -3,16 +3,31 @@
 package rave
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
+// BankListTTL : How long the list of banks used to check bank codes is cached
+const BankListTTL = 24 * time.Hour
+
+// ErrUnknownBank : Returned when a bank code isn't in the list of banks returned by ListBanks
+var ErrUnknownBank = errors.New("Unknown bank code")
+
 // CalculateIntegrityCheckSum : Calculates the integrity checksum of the data required by the browser
-func (r Rave) CalculateIntegrityCheckSum(data map[string]interface{}) string {
+func (r Rave) CalculateIntegrityCheckSum(data map[string]interface{}) (string, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return "", err
+	}
+
 	// sort the map
 	sortedKeys := []string{}
 	sortedValues := []string{}
@@ -37,39 +52,236 @@ func (r Rave) CalculateIntegrityCheckSum(data map[string]interface{}) string {
 	sha256Payload := strings.Join(sortedValues[:], "")
 
 	// join with secret key
-	sha256Payload += r.GetSecretKey()
+	sha256Payload += secretKey
 
 	// Generate a sha256 hash and convert the bytes to hex
 	integrityCheckSum := fmt.Sprintf("%x", sha256.Sum256([]byte(sha256Payload)))
 
-	return integrityCheckSum
+	return integrityCheckSum, nil
 }
 
 // GetFees : Get fees to be charged for a particular amount/currency
 func (r Rave) GetFees(data map[string]interface{}) ([]byte, error) {
-	err := checkRequiredParameters(data, []string{"amount", "currency"})
+	return r.GetFeesContext(context.Background(), data)
+}
+
+// GetFeesContext : Same as GetFees but honors the cancellation and deadline of ctx
+func (r Rave) GetFeesContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	request := FeeRequest{}
+	err := mapToRequest(data, &request, &request.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	response, _, err := r.getFees(ctx, "GetFees", request, data)
+
+	return response, err
+}
+
+// GetFeesTyped : Typed version of GetFees
+func (r Rave) GetFeesTyped(ctx context.Context, request FeeRequest) (*FeeResponse, error) {
+	_, feeResponse, err := r.getFees(ctx, "GetFeesTyped", request, nil)
+
+	return feeResponse, err
+}
+
+// getFees : Sends a fee request to Rave, methodName is reported in parameter errors
+func (r Rave) getFees(
+	ctx context.Context, methodName string, request FeeRequest, extra map[string]interface{},
+) ([]byte, *FeeResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Currency = currency
+
+	data, err := requestPayload(request, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkRequiredParameters(methodName, data, []string{"amount", "currency"})
+	if err != nil {
+		return nil, nil, err
+	}
 
-	data["PBFPubKey"] = r.GetPublicKey()
+	publicKey, err := r.getPublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data["PBFPubKey"] = publicKey
 	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/fee"
 
-	response, err := MakePostRequest(URL, data)
+	response, err := r.withRetries(ctx, nil, func() ([]byte, error) {
+		return r.makePostRequest(ctx, URL, data)
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// Rave doesn't return the currency so the fees are decoded in the currency of the request
+	feeCurrency := strings.ToUpper(currency)
+	feeResponse := &FeeResponse{Data: FeeQuote{
+		ChargeAmount: Money{Currency: feeCurrency},
+		Fee:          Money{Currency: feeCurrency},
+		MerchantFee:  Money{Currency: feeCurrency},
+		RaveFee:      Money{Currency: feeCurrency},
+	}}
+	err = decodeResponse(response, feeResponse)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return response, nil
+	return response, feeResponse, nil
 }
 
 // ListBanks : List Nigerian banks.
 func (r Rave) ListBanks() ([]byte, error) {
+	return r.ListBanksContext(context.Background())
+}
+
+// ListBanksContext : Same as ListBanks but honors the cancellation and deadline of ctx
+func (r Rave) ListBanksContext(ctx context.Context) ([]byte, error) {
+	return r.withRetries(ctx, nil, func() ([]byte, error) {
+		return r.listBanks(ctx)
+	})
+}
+
+// listBanks : Sends a single request for the list of banks
+func (r Rave) listBanks(ctx context.Context) ([]byte, error) {
 	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/flwpbf-banks.js?json=1"
-	response, err := http.Get(URL)
+	req, err := http.NewRequest("GET", URL, nil)
+	if err != nil {
+		return nil, &RequestError{Method: "GET", URL: URL, Err: err}
+	}
+	req = req.WithContext(ctx)
+
+	resp, body, err := r.sendRequest(req)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	defer response.Body.Close()
 
-	body, _ := ioutil.ReadAll(response.Body)
+	if !json.Valid(body) {
+		return nil, &APIError{HTTPStatusCode: resp.StatusCode, Message: invalidResponseMessage, Body: body}
+	}
 
 	return body, nil
 }
+
+// ListBanksTyped : Typed version of ListBanks
+func (r Rave) ListBanksTyped(ctx context.Context) ([]Bank, error) {
+	response, err := r.ListBanksContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	banks := []Bank{}
+	err = decodeResponse(response, &banks)
+	if err != nil {
+		return nil, err
+	}
+
+	return banks, nil
+}
+
+// bankCache : Lists of banks shared by copies of a client by base URL, they're fetched again after BankListTTL
+type bankCache struct {
+	mutex    sync.Mutex
+	entries  map[string]cachedBanks
+	fetching map[string]*bankFetch
+}
+
+// cachedBanks : List of banks of an environment and when it was fetched
+type cachedBanks struct {
+	banks   []Bank
+	fetched time.Time
+}
+
+// bankFetch : List of banks being fetched, done is closed once banks or err is set
+type bankFetch struct {
+	done  chan struct{}
+	banks []Bank
+	err   error
+}
+
+// CachedBanks : Same as ListBanksTyped but the list is only fetched once every BankListTTL.
+// The live and test environments are cached separately. Callers that need the list while it's
+// being fetched wait for that fetch or until their ctx is done.
+func (r Rave) CachedBanks(ctx context.Context) ([]Bank, error) {
+	// clients that weren't created with NewClient don't have a cache
+	if r.banks == nil {
+		return r.ListBanksTyped(ctx)
+	}
+
+	URL := r.getBaseURL()
+	for {
+		r.banks.mutex.Lock()
+		entry, ok := r.banks.entries[URL]
+		if ok && time.Since(entry.fetched) <= BankListTTL {
+			r.banks.mutex.Unlock()
+			return append([]Bank{}, entry.banks...), nil
+		}
+
+		fetch, ok := r.banks.fetching[URL]
+		if !ok {
+			fetch = &bankFetch{done: make(chan struct{})}
+			r.banks.fetching[URL] = fetch
+			r.banks.mutex.Unlock()
+
+			return r.fetchBanks(ctx, URL, fetch)
+		}
+		r.banks.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-fetch.done:
+		}
+
+		// the list is fetched again if the fetch was only stopped by the ctx of the caller that started it
+		if fetch.err == nil {
+			return append([]Bank{}, fetch.banks...), nil
+		}
+
+		if !errors.Is(fetch.err, context.Canceled) && !errors.Is(fetch.err, context.DeadlineExceeded) {
+			return nil, fetch.err
+		}
+	}
+}
+
+// fetchBanks : Fetch the list of banks outside the lock, store it and hand it to the callers waiting for it
+func (r Rave) fetchBanks(ctx context.Context, URL string, fetch *bankFetch) ([]Bank, error) {
+	banks, err := r.ListBanksTyped(ctx)
+
+	r.banks.mutex.Lock()
+	if err == nil {
+		r.banks.entries[URL] = cachedBanks{banks: banks, fetched: time.Now()}
+	}
+	delete(r.banks.fetching, URL)
+	fetch.banks, fetch.err = banks, err
+	r.banks.mutex.Unlock()
+	close(fetch.done)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]Bank{}, banks...), nil
+}
+
+// findBank : Find a bank in the cached list of banks, ErrUnknownBank is returned if it doesn't exist
+func (r Rave) findBank(ctx context.Context, code string) (*Bank, error) {
+	banks, err := r.CachedBanks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bank := range banks {
+		if bank.Code == code {
+			return &bank, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: \"%s\"", ErrUnknownBank, code)
+}
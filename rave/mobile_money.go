@@ -0,0 +1,145 @@
+/* This file contains the functions/methods for mobile money charges */
+
+package rave
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MobileMoneyProvider : Mobile money service used to charge a customer
+type MobileMoneyProvider string
+
+// Mobile money providers supported by Rave
+const (
+	MobileMoneyGhana       MobileMoneyProvider = "ghana"
+	MPesa                  MobileMoneyProvider = "mpesa"
+	MobileMoneyUganda      MobileMoneyProvider = "uganda"
+	MobileMoneyRwanda      MobileMoneyProvider = "rwanda"
+	MobileMoneyZambia      MobileMoneyProvider = "zambia"
+	MobileMoneyFrancophone MobileMoneyProvider = "francophone"
+)
+
+// Networks accepted by Ghana mobile money
+const (
+	NetworkMTN      = "MTN"
+	NetworkVodafone = "VODAFONE"
+	NetworkTigo     = "TIGO"
+)
+
+// parameters required by every mobile money charge, the currency is only required by providers that support several
+var mobileMoneyParameters = []string{
+	"amount", "email", "phonenumber", "firstname", "lastname", "IP", "txRef", "orderRef",
+}
+
+// mobileMoneyProviders : Payload of every provider, Rave reuses the Ghana and
+// Uganda flags for Rwanda and Zambia
+var mobileMoneyProviders = map[MobileMoneyProvider]paymentMethod{
+	MobileMoneyGhana: {
+		name: "Ghana mobile money", paymentType: "mobilemoneygh", country: "GH", currencies: []string{"GHS"},
+		flags: []string{"is_mobile_money_gh"},
+	},
+	MPesa: {
+		name: "M-Pesa", paymentType: "mpesa", country: "KE", currencies: []string{"KES"},
+		flags: []string{"is_mpesa", "is_mpesa_lipa"},
+	},
+	MobileMoneyUganda: {
+		name: "Uganda mobile money", paymentType: "mobilemoneyuganda", country: "NG", currencies: []string{"UGX"},
+		flags: []string{"is_mobile_money_ug"}, network: "UGX",
+	},
+	MobileMoneyRwanda: {
+		name: "Rwanda mobile money", paymentType: "mobilemoneygh", country: "NG", currencies: []string{"RWF"},
+		flags: []string{"is_mobile_money_gh"}, network: "RWF",
+	},
+	MobileMoneyZambia: {
+		name: "Zambia mobile money", paymentType: "mobilemoneyzambia", country: "NG", currencies: []string{"ZMW"},
+		flags: []string{"is_mobile_money_ug"}, network: "MTN",
+	},
+	MobileMoneyFrancophone: {
+		name: "Francophone mobile money", paymentType: "mobilemoneyfranco", country: "NG", currencies: []string{"XAF", "XOF"},
+		flags: []string{"is_mobile_money_franco"},
+	},
+}
+
+// ChargeMobileMoney : Charge a customer's mobile money wallet. The payment_type,
+// flags and country of the provider are set for you.
+// The charge is usually pending until the customer approves it on their phone,
+// use VerifyTransaction to confirm it.
+func (r Rave) ChargeMobileMoney(provider MobileMoneyProvider, data map[string]interface{}) ([]byte, error) {
+	return r.ChargeMobileMoneyContext(context.Background(), provider, data)
+}
+
+// ChargeMobileMoneyContext : Same as ChargeMobileMoney but honors the cancellation and deadline of ctx
+func (r Rave) ChargeMobileMoneyContext(ctx context.Context, provider MobileMoneyProvider, data map[string]interface{}) ([]byte, error) {
+	chargeData, err := mobileMoneyCharge("ChargeMobileMoney", provider, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.chargeMethod(ctx, chargeData)
+}
+
+// ChargeMobileMoneyTyped : Typed version of ChargeMobileMoney
+func (r Rave) ChargeMobileMoneyTyped(ctx context.Context, request MobileMoneyChargeRequest) (*ChargeResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+	request.Currency = currency
+
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeData, err := mobileMoneyCharge("ChargeMobileMoneyTyped", request.Provider, data)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.chargeMethod(ctx, chargeData)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeResponse := &ChargeResponse{}
+	err = decodeResponse(response, chargeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return chargeResponse, nil
+}
+
+// mobileMoneyCharge : Check the parameters of a mobile money charge and add the provider's fields
+func mobileMoneyCharge(methodName string, provider MobileMoneyProvider, data map[string]interface{}) (map[string]interface{}, error) {
+	method, ok := mobileMoneyProviders[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: \"%s\"", ErrUnknownMobileMoneyProvider, provider)
+	}
+
+	chargeData, err := methodCharge(methodName, method, mobileMoneyParameters, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ghana customers choose their network, Vodafone charges need a voucher generated on the phone
+	if provider == MobileMoneyGhana {
+		err := checkRequiredParameters(methodName, chargeData, []string{"network"})
+		if err != nil {
+			return nil, err
+		}
+
+		network := strings.ToUpper(fmt.Sprint(chargeData["network"]))
+		chargeData["network"] = network
+		if network == NetworkVodafone {
+			err := checkRequiredParameters(methodName, chargeData, []string{"voucher"})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return chargeData, nil
+}
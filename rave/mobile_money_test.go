@@ -0,0 +1,159 @@
+// Tests for mobile money charges
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// Every provider should send it's payment_type, flags, country and network
+func TestChargeMobileMoneyPayload(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	testCases := []struct {
+		provider                            MobileMoneyProvider
+		amount                              Money
+		paymentType, flag, country, network string
+	}{
+		{MobileMoneyGhana, MustMoney("50", "GHS"), "mobilemoneygh", "is_mobile_money_gh", "GH", "MTN"},
+		{MPesa, MustMoney("1500", "KES"), "mpesa", "is_mpesa", "KE", ""},
+		{MobileMoneyUganda, MustMoney("5000", "UGX"), "mobilemoneyuganda", "is_mobile_money_ug", "NG", "UGX"},
+		{MobileMoneyRwanda, MustMoney("5000", "RWF"), "mobilemoneygh", "is_mobile_money_gh", "NG", "RWF"},
+		{MobileMoneyZambia, MustMoney("100", "ZMW"), "mobilemoneyzambia", "is_mobile_money_ug", "NG", "MTN"},
+		{MobileMoneyFrancophone, MustMoney("5000", "XAF"), "mobilemoneyfranco", "is_mobile_money_franco", "NG", ""},
+	}
+
+	for i, testCase := range testCases {
+		request := MobileMoneyChargeRequest{
+			Customer: customer, Provider: testCase.provider, Amount: testCase.amount,
+			TxRef: "momo-" + string(testCase.provider), OrderRef: "order-" + string(testCase.provider),
+		}
+		if testCase.provider == MobileMoneyGhana {
+			request.Network = "mtn"
+		}
+
+		response, err := client.ChargeMobileMoneyTyped(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.provider, err)
+		}
+		assertEqual(t, response.Data.Pending(), true)
+		assertEqual(t, response.Data.Amount, testCase.amount)
+
+		payload := server.Charges()[i]
+		assertEqual(t, payload["payment_type"], testCase.paymentType)
+		assertEqual(t, fmt.Sprint(payload[testCase.flag]), "1")
+		assertEqual(t, payload["country"], testCase.country)
+		assertEqual(t, payload["currency"], testCase.amount.Currency)
+		if testCase.network != "" {
+			assertEqual(t, payload["network"], testCase.network)
+		}
+	}
+}
+
+// The currency should only be required by providers that support more than one
+func TestChargeMobileMoneyDefaultCurrency(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	data := func() map[string]interface{} {
+		return map[string]interface{}{
+			"amount": "1500", "email": "user@example.com", "phonenumber": "0926420185", "firstname": "Wanjiru",
+			"lastname": "Kamau", "IP": "127.0.0.1", "txRef": "momo-currency", "orderRef": "order-currency",
+		}
+	}
+
+	if _, err := client.ChargeMobileMoney(MPesa, data()); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, server.Charges()[0]["currency"], "KES")
+
+	_, err := client.ChargeMobileMoney(MobileMoneyFrancophone, data())
+	assertEqual(t, err.Error(), "\"currency\" is a required parameter for \"ChargeMobileMoney\"")
+	assertEqual(t, server.Requests(ravetest.ChargePath), 1)
+}
+
+// A pending charge should only be verified once the customer approves it
+func TestChargeMobileMoneyVerification(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	response, err := client.ChargeMobileMoney(MPesa, map[string]interface{}{
+		"amount": "1500", "currency": "kes", "email": "user@example.com", "phonenumber": "0926420185",
+		"firstname": "Wanjiru", "lastname": "Kamau", "IP": "127.0.0.1", "txRef": "mpesa-1", "orderRef": "order-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chargeResponse := &ChargeResponse{}
+	if err := decodeResponse(response, chargeResponse); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, chargeResponse.Data.Pending(), true)
+
+	verifyRequest := VerifyRequest{FlwRef: chargeResponse.Data.FlwRef, Amount: MustMoney("1500", "KES"), Currency: "KES"}
+	_, err = client.VerifyTransactionTyped(ctx, verifyRequest)
+	verificationError := &VerificationError{}
+	if !errors.As(err, &verificationError) || !verificationError.Failed(RuleChargeResponse) {
+		t.Fatalf("Expected a pending charge to fail verification got %v", err)
+	}
+
+	assertEqual(t, server.ApprovePayment(chargeResponse.Data.FlwRef), true)
+
+	if _, err := client.VerifyTransactionTyped(ctx, verifyRequest); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Missing fields, unsupported currencies and providers should be reported before any request is made
+func TestChargeMobileMoneyParameters(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	request := MobileMoneyChargeRequest{
+		Customer: customer, Provider: MobileMoneyGhana, Amount: MustMoney("50", "GHS"), TxRef: "gh-1", OrderRef: "order-1",
+	}
+	_, err := client.ChargeMobileMoneyTyped(ctx, request)
+	assertEqual(t, err.Error(), "\"network\" is a required parameter for \"ChargeMobileMoneyTyped\"")
+
+	request.Network = NetworkVodafone
+	_, err = client.ChargeMobileMoneyTyped(ctx, request)
+	assertEqual(t, err.Error(), "\"voucher\" is a required parameter for \"ChargeMobileMoneyTyped\"")
+
+	request.Provider, request.Network, request.Amount = MPesa, "", MustMoney("1500", "NGN")
+	_, err = client.ChargeMobileMoneyTyped(ctx, request)
+	if !errors.Is(err, ErrUnsupportedCurrency) || !IsValidationError(err) {
+		t.Errorf("Expected ErrUnsupportedCurrency got %v", err)
+	}
+
+	request.Provider, request.Amount = "tanzania", MustMoney("1500", "TZS")
+	_, err = client.ChargeMobileMoneyTyped(ctx, request)
+	if !errors.Is(err, ErrUnknownMobileMoneyProvider) {
+		t.Errorf("Expected ErrUnknownMobileMoneyProvider got %v", err)
+	}
+
+	assertEqual(t, server.Requests(ravetest.ChargePath), 0)
+}
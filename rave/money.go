@@ -0,0 +1,256 @@
+/*
+This file contains the Money type used for amounts and fees.
+
+Money is stored as an integer number of minor units (kobo, cents ...) with
+an ISO 4217 currency code so amounts are never rounded by floating point
+arithmetic. It's encoded as a JSON number with as many decimals as the
+currency has ("1052.50" for NGN, "5000" for UGX) which is what Rave accepts
+and returns.
+*/
+
+package rave
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// defaultExponent : Number of decimals used for currencies that aren't in currencyExponents
+const defaultExponent = 2
+
+// currencyExponents : Number of decimals of currencies that don't use two decimals
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent : Number of decimals of an ISO 4217 currency (2 for NGN, KES, GHS, USD, ZAR ...)
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+
+	return defaultExponent
+}
+
+// ErrCurrencyMismatch : Returned when amounts in different currencies are combined or compared
+var ErrCurrencyMismatch = errors.New("Amounts are in different currencies")
+
+// Money : An amount in the minor units of a currency (e.g 105250 NGN is ₦1052.50)
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// NewMoney : Parse a decimal amount ("1052.50") in the given currency. An error
+// is returned if the amount has more decimals than the currency allows.
+func NewMoney(amount, currency string) (Money, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok || strings.Contains(amount, "/") {
+		return Money{}, fmt.Errorf("'%s' is not a valid amount", amount)
+	}
+
+	currency = strings.ToUpper(currency)
+	minor := new(big.Rat).Mul(value, scale(CurrencyExponent(currency)))
+	if !minor.IsInt() {
+		return Money{}, fmt.Errorf("'%s' has more decimals than %s allows", amount, currencyName(currency))
+	}
+
+	if !minor.Num().IsInt64() {
+		return Money{}, fmt.Errorf("'%s' is too large", amount)
+	}
+
+	return Money{Minor: minor.Num().Int64(), Currency: currency}, nil
+}
+
+// MustMoney : Same as NewMoney but panics if the amount is invalid, it's meant for constants
+func MustMoney(amount, currency string) Money {
+	money, err := NewMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+
+	return money
+}
+
+// MoneyFromMinor : Create an amount from minor units (e.g kobo or cents)
+func MoneyFromMinor(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: strings.ToUpper(currency)}
+}
+
+// IsZero : Reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// Decimal : The amount with as many decimals as the currency has ("1052.50")
+func (m Money) Decimal() string {
+	return m.rat().FloatString(CurrencyExponent(m.Currency))
+}
+
+// String : The amount followed by it's currency ("1052.50 NGN")
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+
+	return m.Decimal() + " " + m.Currency
+}
+
+// Add : Returns m + other, both amounts must be in the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Minor: m.Minor + other.Minor, Currency: firstNonEmpty(m.Currency, other.Currency)}, nil
+}
+
+// Sub : Returns m - other, both amounts must be in the same currency
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Minor: m.Minor - other.Minor, Currency: firstNonEmpty(m.Currency, other.Currency)}, nil
+}
+
+// Cmp : Returns -1, 0 or +1 if m is less than, equal to or greater than other
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.Minor < other.Minor:
+		return -1, nil
+	case m.Minor > other.Minor:
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// MarshalJSON : Encode the amount as a JSON number ("1052.50"), the zero value is encoded as null
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m == (Money{}) {
+		return []byte("null"), nil
+	}
+
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON : Decode an amount sent as a number (1052.5) or a string ("1052.50").
+// The currency of m (two decimals if it's not set) is used, amounts with more
+// decimals than the currency allows are rounded half away from zero.
+func (m *Money) UnmarshalJSON(body []byte) error {
+	body = bytes.TrimSpace(body)
+	if string(body) == "null" || string(body) == `""` {
+		m.Minor = 0
+		return nil
+	}
+
+	amount := string(body)
+	if body[0] == '"' {
+		if err := json.Unmarshal(body, &amount); err != nil {
+			return err
+		}
+	}
+
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok || strings.Contains(amount, "/") {
+		return fmt.Errorf("'%s' is not a valid amount", amount)
+	}
+
+	minor := roundHalfAway(new(big.Rat).Mul(value, scale(CurrencyExponent(m.Currency))))
+	if !minor.IsInt64() {
+		return fmt.Errorf("'%s' is too large", amount)
+	}
+
+	m.Minor = minor.Int64()
+
+	return nil
+}
+
+// rat : The amount as an exact decimal
+func (m Money) rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.Minor), scale(CurrencyExponent(m.Currency)).Num())
+}
+
+// checkCurrency : Amounts without a currency can be combined with any currency
+func (m Money) checkCurrency(other Money) error {
+	if m.Currency != "" && other.Currency != "" && !strings.EqualFold(m.Currency, other.Currency) {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+
+	return nil
+}
+
+// scale : Returns 10^exponent
+func scale(exponent int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil))
+}
+
+// roundHalfAway : Round a rational number to the nearest integer, halves are rounded away from zero
+func roundHalfAway(value *big.Rat) *big.Int {
+	half := big.NewRat(1, 2)
+	if value.Sign() < 0 {
+		half.Neg(half)
+	}
+
+	rounded := new(big.Rat).Add(value, half)
+
+	// Quo truncates towards zero
+	return new(big.Int).Quo(rounded.Num(), rounded.Denom())
+}
+
+// currencyName : Name of a currency for error messages
+func currencyName(currency string) string {
+	if currency == "" {
+		return "an amount without a currency"
+	}
+
+	return currency
+}
+
+// moneyFromValue : Convert an amount from a map request ("1052.50", 1052.5, json.Number, Money ...) to Money
+func moneyFromValue(amount interface{}, currency string) (Money, error) {
+	var value string
+	switch amount := amount.(type) {
+	case Money:
+		return amount, nil
+	case string:
+		value = amount
+	case json.Number:
+		value = amount.String()
+	case float64:
+		value = strconv.FormatFloat(amount, 'f', -1, 64)
+	case float32:
+		value = strconv.FormatFloat(float64(amount), 'f', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		value = fmt.Sprint(amount)
+	default:
+		return Money{}, fmt.Errorf("the amount has an unsupported type %T", amount)
+	}
+
+	return NewMoney(value, currency)
+}
+
+// requestCurrency : Returns the currency of a typed request, it's taken from the amount when it isn't set
+func requestCurrency(currency string, amount Money) (string, error) {
+	if currency == "" {
+		return amount.Currency, nil
+	}
+
+	if amount.Currency != "" && !strings.EqualFold(currency, amount.Currency) {
+		return "", fmt.Errorf("%w: the currency is %s but the amount is in %s", ErrCurrencyMismatch, currency, amount.Currency)
+	}
+
+	return currency, nil
+}
@@ -0,0 +1,141 @@
+// Tests for the Money type
+
+package rave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// Amounts should be parsed exactly with the exponent of their currency
+func TestNewMoney(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount   string
+		currency string
+		minor    int64
+		decimal  string
+		valid    bool
+	}{
+		{"1052.50", "NGN", 105250, "1052.50", true},
+		{"1052.5", "ngn", 105250, "1052.50", true},
+		{"300", "KES", 30000, "300.00", true},
+		{"0.1", "GHS", 10, "0.10", true},
+		{"19.99", "USD", 1999, "19.99", true},
+		{"100.05", "ZAR", 10005, "100.05", true},
+		{"5000", "UGX", 5000, "5000", true},
+		{"1.234", "KWD", 1234, "1.234", true},
+		{"1052.505", "NGN", 0, "", false},
+		{"50.5", "UGX", 0, "", false},
+		{"1/2", "NGN", 0, "", false},
+		{"abc", "NGN", 0, "", false},
+	}
+
+	for _, test := range tests {
+		money, err := NewMoney(test.amount, test.currency)
+		if (err == nil) != test.valid {
+			t.Errorf("%s %s: expected valid=%v got %v", test.amount, test.currency, test.valid, err)
+			continue
+		}
+
+		if test.valid {
+			assertEqual(t, money.Minor, test.minor)
+			assertEqual(t, money.Decimal(), test.decimal)
+		}
+	}
+}
+
+// Money should be encoded as a JSON number and decoded from numbers or strings
+func TestMoneyJSON(t *testing.T) {
+	t.Parallel()
+
+	body, err := json.Marshal(map[string]interface{}{"amount": MustMoney("1052.50", "NGN"), "unset": Money{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(body), `{"amount":1052.50,"unset":null}`)
+
+	for body, minor := range map[string]int64{`1052.5`: 105250, `"1052.50"`: 105250, `0.125`: 13, `-0.125`: -13, `null`: 0} {
+		money := Money{Currency: "NGN"}
+		if err := json.Unmarshal([]byte(body), &money); err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, money.Minor, minor)
+		assertEqual(t, money.Currency, "NGN")
+	}
+
+	money := Money{Currency: "UGX"}
+	if err := json.Unmarshal([]byte(`5000`), &money); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, money.Minor, int64(5000))
+
+	if err := json.Unmarshal([]byte(`"abc"`), &money); err == nil {
+		t.Error("Expected an error for an invalid amount")
+	}
+}
+
+// Amounts in the response should be decoded in the currency of the transaction
+func TestChargeDataCurrency(t *testing.T) {
+	t.Parallel()
+
+	response := ChargeResponse{}
+	err := json.Unmarshal([]byte(`{"data": {"amount": 5000, "charged_amount": "5000", "appfee": 70, "currency": "UGX"}}`), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, response.Data.Amount, MoneyFromMinor(5000, "UGX"))
+	assertEqual(t, response.Data.ChargedAmount, MoneyFromMinor(5000, "UGX"))
+	assertEqual(t, response.Data.AppFee, MoneyFromMinor(70, "UGX"))
+}
+
+// Only amounts in the same currency can be combined
+func TestMoneyArithmetic(t *testing.T) {
+	t.Parallel()
+
+	quote := FeeQuote{Fee: MustMoney("14.73", "NGN")}
+	total, err := quote.Total(MustMoney("1052.50", "NGN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, total.String(), "1067.23 NGN")
+
+	difference, err := total.Sub(MustMoney("1067.24", "NGN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, difference.Decimal(), "-0.01")
+
+	comparison, err := total.Cmp(MustMoney("1052.50", "NGN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, comparison, 1)
+
+	if _, err := total.Add(MustMoney("1", "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch got %v", err)
+	}
+}
+
+// Typed requests should take their currency from the amount and report a missing amount
+func TestTypedRequestAmount(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(WithKeys("FLWPUBK-public-X", "FLWSECK-secret-key-X"))
+
+	_, err := client.GetFeesTyped(context.Background(), FeeRequest{Currency: "NGN"})
+	parameterError := &ParameterError{}
+	if !errors.As(err, &parameterError) || parameterError.Parameter != "amount" {
+		t.Errorf("Expected the amount to be required got %v", err)
+	}
+
+	_, err = client.GetFeesTyped(context.Background(), FeeRequest{Amount: MustMoney("100", "USD"), Currency: "NGN"})
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch got %v", err)
+	}
+}
@@ -3,66 +3,242 @@
 package rave
 
 import (
-	"github.com/antonholmquist/jason"
+	"context"
+	"fmt"
+	"strings"
 )
 
+// parameters required by every card charge
+var cardChargeParameters = []string{
+	"cardno", "cvv", "expirymonth", "expiryyear", "amount", "email",
+	"phonenumber", "firstname", "lastname", "IP", "txRef", "redirect_url",
+}
+
+// parameters required by every account charge
+var accountChargeParameters = []string{
+	"accountnumber", "accountbank", "email", "phonenumber",
+	"firstname", "lastname", "IP", "txRef", "payment_type",
+}
+
+// parameters required by US (ACH) account charges, the customer authorizes the charge at the auth URL
+var usAccountChargeParameters = []string{
+	"accountnumber", "routingnumber", "amount", "email", "phonenumber",
+	"firstname", "lastname", "IP", "txRef", "redirect_url",
+}
+
+// parameters required by South African account charges, they don't need a BVN
+var zaAccountChargeParameters = []string{
+	"accountnumber", "accountbank", "passcode", "amount", "email", "phonenumber",
+	"firstname", "lastname", "IP", "txRef",
+}
+
+// accountCountries : Payload of account charges outside Nigeria by country
+var accountCountries = map[string]struct {
+	method     paymentMethod
+	parameters []string
+}{
+	"US": {
+		method: paymentMethod{
+			name: "US bank account", paymentType: "account", country: "US", currencies: []string{"USD"},
+			flags: []string{"is_us_bank_charge"},
+		},
+		parameters: usAccountChargeParameters,
+	},
+	"ZA": {
+		method: paymentMethod{
+			name: "South African bank account", paymentType: "account", country: "ZA", currencies: []string{"ZAR"},
+			flags: []string{"is_south_african_bank_account"},
+		},
+		parameters: zaAccountChargeParameters,
+	},
+}
+
+// billing address parameters required by some international cards
+var billingParameters = []string{"billingzip", "billingcity", "billingaddress", "billingstate", "billingcountry"}
+
+// Suggested auth values that require a billing address
+const (
+	suggestedAuthNoAuthInternational = "NOAUTH_INTERNATIONAL"
+	suggestedAuthAVS                 = "AVS_VBVSECURECODE"
+)
+
+// paymentMethod : Fields Rave expects in the charge payload of mobile money and
+// other alternative payment methods
+type paymentMethod struct {
+	// name used in error messages
+	name        string
+	paymentType string
+	country     string
+	currencies  []string
+
+	// flags set to 1 in the payload
+	flags []string
+
+	// network sent with every charge, if any
+	network string
+}
+
 // ChargeCard : Sends a Card request and determine the validation flow to be used
 func (r Rave) ChargeCard(chargeData map[string]interface{}) ([]byte, error) {
-	err := checkRequiredParameters(chargeData, []string{
-		"cardno", "cvv", "expirymonth", "expiryyear", "amount", "email",
-		"phonenumber", "firstname", "lastname", "IP", "txRef", "redirect_url",
-	})
+	return r.ChargeCardContext(context.Background(), chargeData)
+}
+
+// ChargeCardContext : Same as ChargeCard but honors the cancellation and deadline of ctx
+func (r Rave) ChargeCardContext(ctx context.Context, chargeData map[string]interface{}) ([]byte, error) {
+	request := CardChargeRequest{}
+	err := mapToRequest(chargeData, &request, &request.Amount)
 	if err != nil {
 		return nil, err
 	}
 
-	postData := r.setUpCharge(chargeData)
-	response, err := r.charge(postData)
+	response, _, err := r.chargeCard(ctx, "ChargeCard", request, chargeData)
+
+	return response, err
+}
+
+// ChargeCardTyped : Typed version of ChargeCard
+func (r Rave) ChargeCardTyped(ctx context.Context, request CardChargeRequest) (*ChargeResponse, error) {
+	_, chargeResponse, err := r.chargeCard(ctx, "ChargeCardTyped", request, nil)
+
+	return chargeResponse, err
+}
+
+// chargeCard : Contains the card charge logic shared by ChargeCard, ChargeCardTyped and the preauthorizations.
+// extra is the map of map-based charges, methodName is reported in parameter errors.
+func (r Rave) chargeCard(
+	ctx context.Context, methodName string, request CardChargeRequest, extra map[string]interface{},
+) ([]byte, *ChargeResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	request.Currency = currency
+
+	chargeData, err := requestPayload(request, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkRequiredParameters(methodName, chargeData, cardChargeParameters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	postData, err := r.setUpCharge(chargeData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := r.charge(ctx, postData, request.TxRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chargeResponse := &ChargeResponse{}
+	err = decodeResponse(response, chargeResponse)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// If suggested_auth == "PIN" was returned in the response
-	// Encrypt the client's data with the otp details and make another request
-	suggestedAuthData, _ := jason.NewObjectFromBytes(response)
-	data, _ := suggestedAuthData.GetObject("data")
-	suggestedAuth, _ := data.GetString("suggested_auth")
-
-	if suggestedAuth == "PIN" {
-		chargeData["suggested_auth"] = "PIN"
-		err := checkRequiredParameters(chargeData, []string{"pin"})
-		if err != nil {
-			return nil, err
+	// Encrypt the client's data with the PIN and make another request.
+	// The PIN is only sent once, Rave suggesting it again is returned to the caller
+	suggestedAuth := chargeResponse.Data.SuggestedAuth
+	if suggestedAuth == "PIN" && request.SuggestedAuth != "PIN" {
+		request.SuggestedAuth = "PIN"
+		if request.Pin == "" {
+			return nil, nil, &ParameterError{Parameter: "pin", Method: methodName}
 		}
 
-		response, err = r.ChargeCard(chargeData)
+		// don't send the charge again if the caller gave up while the first one was in flight
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		return r.chargeCard(ctx, methodName, request, extra)
+	}
+
+	// International cards may need their billing address (Address Verification System)
+	// Resend the charge with the billing address unless it was already sent
+	if requiresBillingAddress(suggestedAuth) && request.SuggestedAuth != suggestedAuth {
+		request.SuggestedAuth = suggestedAuth
+		err := checkBillingAddress(methodName, request.BillingAddress, suggestedAuth)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		return r.chargeCard(ctx, methodName, request, extra)
 	}
 
-	return response, nil
+	return response, chargeResponse, nil
+}
+
+// requiresBillingAddress : Reports whether Rave suggested an auth model that needs the billing address
+func requiresBillingAddress(suggestedAuth string) bool {
+	suggestedAuth = strings.ToUpper(suggestedAuth)
+
+	return suggestedAuth == suggestedAuthNoAuthInternational || suggestedAuth == suggestedAuthAVS
+}
+
+// checkBillingAddress : Report every missing billing address field at once
+func checkBillingAddress(methodName string, address BillingAddress, suggestedAuth string) error {
+	fields := []string{address.BillingZip, address.BillingCity, address.Address, address.BillingState, address.BillingCountry}
+
+	var missing []string
+	for i, field := range billingParameters {
+		if fields[i] == "" {
+			missing = append(missing, field)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &BillingAddressError{SuggestedAuth: suggestedAuth, Missing: missing, Method: methodName}
+	}
+
+	return nil
 }
 
 // Encrypts and setup a charge (Payment/account) with the secret key and algorithm
-func (r Rave) setUpCharge(chargeData map[string]interface{}) map[string]interface{} {
-	chargeJSON := mapToJSON(chargeData)
-	encryptedchargeData := r.Encrypt3Des(string(chargeJSON[:]))
+func (r Rave) setUpCharge(chargeData map[string]interface{}) (map[string]interface{}, error) {
+	publicKey, err := r.getPublicKey()
+	if err != nil {
+		return nil, err
+	}
 
-	data := map[string]interface{}{
-		"PBFPubKey": r.GetPublicKey(),
-		"client":    encryptedchargeData,
-		"alg":       "3DES-24",
+	chargeJSON, err := mapToJSON(chargeData)
+	if err != nil {
+		return nil, err
 	}
 
-	return data
+	encryptedchargeData, err := r.Encrypt3Des(string(chargeJSON[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptedCharge(publicKey, encryptedchargeData), nil
+}
+
+// encryptedCharge : Body of a charge request with a payload encrypted by Encrypt3Des (or in the browser)
+func encryptedCharge(publicKey, client string) map[string]interface{} {
+	return map[string]interface{}{
+		"PBFPubKey": publicKey,
+		"client":    client,
+		"alg":       "3DES-24",
+	}
 }
 
 // charge: Contains the actual logic for making requests to the charge endpoint
-func (r Rave) charge(data map[string]interface{}) ([]byte, error) {
+// The charge is only retried if the retry policy allows it and txRef is set
+func (r Rave) charge(ctx context.Context, data map[string]interface{}, txRef interface{}) ([]byte, error) {
 	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/charge"
 
-	response, err := MakePostRequest(URL, data)
+	response, err := r.withChargeRetries(ctx, txRef, func() ([]byte, error) {
+		return r.makePostRequest(ctx, URL, data)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -72,55 +248,398 @@ func (r Rave) charge(data map[string]interface{}) ([]byte, error) {
 
 // ValidateCharge : Validate a card charge using OTP
 func (r Rave) ValidateCharge(data map[string]interface{}) ([]byte, error) {
-	err := checkRequiredParameters(data, []string{"transaction_reference", "otp"})
+	return r.ValidateChargeContext(context.Background(), data)
+}
+
+// ValidateChargeContext : Same as ValidateCharge but honors the cancellation and deadline of ctx
+func (r Rave) ValidateChargeContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	request := ValidateChargeRequest{}
+	err := mapToRequest(data, &request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	data["PBFPubKey"] = r.GetPublicKey()
+	response, _, err := r.validateCharge(ctx, "ValidateCharge", request, data)
+
+	return response, err
+}
+
+// ValidateChargeTyped : Typed version of ValidateCharge
+func (r Rave) ValidateChargeTyped(ctx context.Context, request ValidateChargeRequest) (*ValidateChargeResponse, error) {
+	_, validateResponse, err := r.validateCharge(ctx, "ValidateChargeTyped", request, nil)
+
+	return validateResponse, err
+}
+
+// validateCharge : Sends the OTP for a card charge to Rave
+func (r Rave) validateCharge(
+	ctx context.Context, methodName string, request ValidateChargeRequest, extra map[string]interface{},
+) ([]byte, *ValidateChargeResponse, error) {
+	data, err := requestPayload(request, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkRequiredParameters(methodName, data, []string{"transaction_reference", "otp"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKey, err := r.getPublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data["PBFPubKey"] = publicKey
 	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/validatecharge"
 
-	response, err := MakePostRequest(URL, data)
+	response, err := r.makePostRequest(ctx, URL, data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return response, nil
+	validateResponse := &ValidateChargeResponse{}
+	err = decodeResponse(response, validateResponse)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return response, validateResponse, nil
 }
 
-// ChargeAccount : Charge a Local (Nigerian) or South African Bank Account
+// ChargeAccount : Charge a Local (Nigerian) or South African Bank Account.
+// Some banks authorize the charge on their internet banking page instead of
+// with an OTP, the AuthFlow of the charge reports which one is used.
 func (r Rave) ChargeAccount(data map[string]interface{}) ([]byte, error) {
-	err := checkRequiredParameters(data, []string{
-		"accountnumber", "accountbank", "email", "phonenumber",
-		"firstname", "lastname", "IP", "txRef", "payment_type",
-	})
+	return r.ChargeAccountContext(context.Background(), data)
+}
+
+// ChargeAccountContext : Same as ChargeAccount but honors the cancellation and deadline of ctx
+func (r Rave) ChargeAccountContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	var response []byte
+	var err error
+
+	// the charge is made with the typed request of the country it's made in
+	country, _ := data["country"].(string)
+	switch strings.ToUpper(country) {
+	case "US":
+		request := USAccountChargeRequest{}
+		if err = mapToRequest(data, &request, &request.Amount); err == nil {
+			response, _, err = r.chargeUSAccount(ctx, "ChargeAccount", request, data)
+		}
+
+	case "ZA":
+		request := SouthAfricanAccountChargeRequest{}
+		if err = mapToRequest(data, &request, &request.Amount); err == nil {
+			response, _, err = r.chargeSouthAfricanAccount(ctx, "ChargeAccount", request, data)
+		}
+
+	default:
+		request := AccountChargeRequest{}
+		if err = mapToRequest(data, &request, &request.Amount); err == nil {
+			response, _, err = r.chargeAccount(ctx, "ChargeAccount", request, data)
+		}
+	}
+
+	return response, err
+}
+
+// ChargeAccountTyped : Typed version of ChargeAccount
+func (r Rave) ChargeAccountTyped(ctx context.Context, request AccountChargeRequest) (*ChargeResponse, error) {
+	_, chargeResponse, err := r.chargeAccount(ctx, "ChargeAccountTyped", request, nil)
+
+	return chargeResponse, err
+}
+
+// ChargeUSAccountTyped : Charge a US bank account through ACH, the customer authorizes the
+// charge at the AuthURL of the response (AuthFlowRedirect)
+func (r Rave) ChargeUSAccountTyped(ctx context.Context, request USAccountChargeRequest) (*ChargeResponse, error) {
+	_, chargeResponse, err := r.chargeUSAccount(ctx, "ChargeUSAccountTyped", request, nil)
+
+	return chargeResponse, err
+}
+
+// ChargeSouthAfricanAccountTyped : Charge a South African bank account
+func (r Rave) ChargeSouthAfricanAccountTyped(
+	ctx context.Context, request SouthAfricanAccountChargeRequest,
+) (*ChargeResponse, error) {
+	_, chargeResponse, err := r.chargeSouthAfricanAccount(ctx, "ChargeSouthAfricanAccountTyped", request, nil)
+
+	return chargeResponse, err
+}
+
+// chargeAccount : Charge an account in the country of the request, Nigerian accounts are
+// resolved first if the client checks account names or ExpectedAccountName is set
+func (r Rave) chargeAccount(
+	ctx context.Context, methodName string, request AccountChargeRequest, extra map[string]interface{},
+) ([]byte, *ChargeResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	request.Currency = currency
 
-	postData := r.setUpCharge(data)
-	response, err := r.charge(postData)
+	data, err := requestPayload(request, extra)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return response, nil
+	country := strings.ToUpper(request.Country)
+	if _, ok := accountCountries[country]; ok {
+		return r.sendAccountCharge(ctx, methodName, country, request.TxRef, data)
+	}
+
+	err = checkRequiredParameters(methodName, data, accountChargeParameters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if country == "" || country == "NG" {
+		err = r.checkAccountName(ctx, data, "accountnumber", "accountbank", request.FirstName, request.LastName)
+	} else {
+		err = skipAccountName(data, fmt.Sprintf("accounts in \"%s\" can't be resolved", request.Country))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.sendCharge(ctx, request.TxRef, data)
+}
+
+// chargeUSAccount : Charge a US bank account, the routing number is checked before it's sent
+func (r Rave) chargeUSAccount(
+	ctx context.Context, methodName string, request USAccountChargeRequest, extra map[string]interface{},
+) ([]byte, *ChargeResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Currency = currency
+
+	if request.RoutingNumber != "" && !validRoutingNumber(request.RoutingNumber) {
+		return nil, nil, fmt.Errorf("%w: \"%s\"", ErrInvalidRoutingNumber, request.RoutingNumber)
+	}
+
+	data, err := requestPayload(request, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.sendAccountCharge(ctx, methodName, "US", request.TxRef, data)
+}
+
+// chargeSouthAfricanAccount : Charge a South African bank account
+func (r Rave) chargeSouthAfricanAccount(
+	ctx context.Context, methodName string, request SouthAfricanAccountChargeRequest, extra map[string]interface{},
+) ([]byte, *ChargeResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Currency = currency
+
+	data, err := requestPayload(request, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.sendAccountCharge(ctx, methodName, "ZA", request.TxRef, data)
+}
+
+// sendAccountCharge : Check and send the payload of an account charge outside Nigeria,
+// it gets the flags, payment_type and currency of the country. The name of these accounts
+// can't be resolved so "expected_account_name" is rejected.
+func (r Rave) sendAccountCharge(
+	ctx context.Context, methodName, country, txRef string, data map[string]interface{},
+) ([]byte, *ChargeResponse, error) {
+	settings := accountCountries[country]
+
+	chargeData, err := methodCharge(methodName, settings.method, settings.parameters, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	chargeData["country"] = settings.method.country
+
+	err = skipAccountName(chargeData, fmt.Sprintf("accounts in \"%s\" can't be resolved", country))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.sendCharge(ctx, txRef, chargeData)
+}
+
+// validRoutingNumber : Reports whether number is a 9 digit ABA routing number with a valid checksum
+func validRoutingNumber(number string) bool {
+	if len(number) != 9 {
+		return false
+	}
+
+	weights := []int{3, 7, 1}
+	sum := 0
+	for i, digit := range number {
+		if digit < '0' || digit > '9' {
+			return false
+		}
+
+		sum += int(digit-'0') * weights[i%3]
+	}
+
+	return sum%10 == 0
+}
+
+// sendCharge : Encrypts and sends a checked charge payload and decodes the response
+func (r Rave) sendCharge(ctx context.Context, txRef string, data map[string]interface{}) ([]byte, *ChargeResponse, error) {
+	postData, err := r.setUpCharge(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := r.charge(ctx, postData, txRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chargeResponse := &ChargeResponse{}
+	err = decodeResponse(response, chargeResponse)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return response, chargeResponse, nil
 }
 
-// ValidateAccountCharge : Validate an account charge using OTP
+// ValidateAccountCharge : Validate an account charge using OTP.
+// Charges authorized on the bank's page (AuthFlowRedirect) are verified with VerifyRedirect instead.
 func (r Rave) ValidateAccountCharge(data map[string]interface{}) ([]byte, error) {
-	err := checkRequiredParameters(data, []string{"transactionreference", "otp"})
+	return r.ValidateAccountChargeContext(context.Background(), data)
+}
+
+// ValidateAccountChargeContext : Same as ValidateAccountCharge but honors the cancellation and deadline of ctx
+func (r Rave) ValidateAccountChargeContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	request := ValidateAccountChargeRequest{}
+	err := mapToRequest(data, &request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	data["PBFPubKey"] = r.GetPublicKey()
+	response, _, err := r.validateAccountCharge(ctx, "ValidateAccountCharge", request, data)
+
+	return response, err
+}
+
+// ValidateAccountChargeTyped : Typed version of ValidateAccountCharge
+func (r Rave) ValidateAccountChargeTyped(ctx context.Context, request ValidateAccountChargeRequest) (*ChargeResponse, error) {
+	_, chargeResponse, err := r.validateAccountCharge(ctx, "ValidateAccountChargeTyped", request, nil)
+
+	return chargeResponse, err
+}
+
+// validateAccountCharge : Sends the OTP for an account charge to Rave
+func (r Rave) validateAccountCharge(
+	ctx context.Context, methodName string, request ValidateAccountChargeRequest, extra map[string]interface{},
+) ([]byte, *ChargeResponse, error) {
+	data, err := requestPayload(request, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkRequiredParameters(methodName, data, []string{"transactionreference", "otp"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKey, err := r.getPublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data["PBFPubKey"] = publicKey
 	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/validate"
 
-	response, err := MakePostRequest(URL, data)
+	response, err := r.makePostRequest(ctx, URL, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chargeResponse := &ChargeResponse{}
+	err = decodeResponse(response, chargeResponse)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return response, chargeResponse, nil
+}
+
+// methodCharge : Check the parameters of a charge made with method and add the fields of the method.
+// data isn't modified so it can be reused for another charge, methodName is reported in parameter errors.
+func methodCharge(
+	methodName string, method paymentMethod, parameters []string, data map[string]interface{},
+) (map[string]interface{}, error) {
+	err := checkRequiredParameters(methodName, data, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeData := make(map[string]interface{}, len(data)+len(method.flags)+4)
+	for key, value := range data {
+		chargeData[key] = value
+	}
+
+	// the currency is optional for methods that only support one
+	currency, ok := chargeData["currency"]
+	if !ok {
+		if len(method.currencies) != 1 {
+			return nil, &ParameterError{Parameter: "currency", Method: methodName}
+		}
+		currency = method.currencies[0]
+	}
+
+	chargeData["currency"] = strings.ToUpper(fmt.Sprint(currency))
+	if !containsString(method.currencies, chargeData["currency"].(string)) {
+		return nil, fmt.Errorf(
+			"%w: %s charges must be in %s not %s",
+			ErrUnsupportedCurrency, method.name, strings.Join(method.currencies, " or "), chargeData["currency"],
+		)
+	}
+
+	chargeData["payment_type"] = method.paymentType
+	for _, flag := range method.flags {
+		chargeData[flag] = 1
+	}
+
+	if _, ok := chargeData["country"]; !ok {
+		chargeData["country"] = method.country
+	}
+
+	if method.network != "" {
+		chargeData["network"] = method.network
+	}
+
+	return chargeData, nil
+}
+
+// chargeMethod : Encrypts and sends a charge built by methodCharge
+func (r Rave) chargeMethod(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	postData, err := r.setUpCharge(data)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.charge(ctx, postData, data["txRef"])
 	if err != nil {
 		return nil, err
 	}
 
 	return response, nil
 }
+
+// containsString : Reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+// Tests for card charges that need the card's billing address
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// ChargeCard should resend international charges with the billing address
+func TestChargeCardBillingAddress(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	charges := 0
+	for card, code := range map[string]string{ravetest.InternationalCard: "00", ravetest.AVSCard: "02"} {
+		request := sessionCharge(card)
+		request.BillingAddress = BillingAddress{
+			BillingZip: "07205", BillingCity: "Hillside", Address: "470 Mundet PI", BillingState: "NJ", BillingCountry: "US",
+		}
+
+		response, err := client.ChargeCardTyped(ctx, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, response.Data.ChargeResponseCode, code)
+
+		charges += 2
+		sent := server.Charges()
+		assertEqual(t, len(sent), charges)
+		assertEqual(t, sent[charges-1]["billingzip"], "07205")
+	}
+}
+
+// Every missing billing field should be reported before the charge is resent
+func TestChargeCardMissingBillingAddress(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	request := sessionCharge(ravetest.AVSCard)
+	request.BillingAddress = BillingAddress{BillingCity: "Hillside", BillingCountry: "US"}
+
+	_, err := client.ChargeCardTyped(context.Background(), request)
+	billingAddressError := &BillingAddressError{}
+	if !errors.As(err, &billingAddressError) {
+		t.Fatalf("Expected a BillingAddressError got %v", err)
+	}
+	assertEqual(t, billingAddressError.SuggestedAuth, "AVS_VBVSECURECODE")
+	assertEqual(t, strings.Join(billingAddressError.Missing, ","), "billingzip,billingaddress,billingstate")
+	assertEqual(t, err.Error(), "Rave requires the billing address of this card (AVS_VBVSECURECODE), "+
+		"missing \"billingzip\", \"billingaddress\", \"billingstate\" for \"ChargeCardTyped\"")
+	assertEqual(t, IsValidationError(err), true)
+	assertEqual(t, server.Requests(ravetest.ChargePath), 1)
+
+	_, err = client.ChargeCard(map[string]interface{}{
+		"cardno": ravetest.InternationalCard, "cvv": "789", "expirymonth": "09", "expiryyear": "25",
+		"amount": "300", "currency": "NGN", "email": "user@example.com", "phonenumber": "0902620185",
+		"firstname": "Temi", "lastname": "Adebayo", "IP": "127.0.0.1", "txRef": "billing-map",
+		"redirect_url": "https://example.com/callback",
+	})
+	if !errors.As(err, &billingAddressError) || len(billingAddressError.Missing) != 5 {
+		t.Fatalf("Expected every billing field to be missing got %v", err)
+	}
+	assertEqual(t, billingAddressError.Method, "ChargeCard")
+}
+
+// The PIN should only be sent once even if Rave keeps suggesting it
+func TestChargeCardPINSuggestedTwice(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	suggestPIN := ravetest.Response{
+		StatusCode: 200,
+		Body:       `{"status": "success", "message": "AUTH_SUGGESTION", "data": {"suggested_auth": "PIN"}}`,
+	}
+	server.Script(ravetest.ChargePath, suggestPIN, suggestPIN)
+
+	request := sessionCharge(ravetest.PinCard)
+	request.Pin = "3310"
+
+	response, err := client.ChargeCardTyped(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, response.Data.SuggestedAuth, "PIN")
+	assertEqual(t, server.Requests(ravetest.ChargePath), 2)
+}
@@ -2,48 +2,231 @@
 
 package rave
 
+import (
+	"context"
+	"fmt"
+)
+
 // PreauthorizeCard : This is just a wrapper arond the ChargeCard method
 // that automatically sets "charge_type" to "preauth"
 func (r Rave) PreauthorizeCard(chargeData map[string]interface{}) ([]byte, error) {
-	chargeData["charge_type"] = "preauth"
+	return r.PreauthorizeCardContext(context.Background(), chargeData)
+}
 
-	response, err := r.ChargeCard(chargeData)
+// PreauthorizeCardContext : Same as PreauthorizeCard but honors the cancellation and deadline of ctx
+func (r Rave) PreauthorizeCardContext(ctx context.Context, chargeData map[string]interface{}) ([]byte, error) {
+	request := CardChargeRequest{}
+	err := mapToRequest(chargeData, &request, &request.Amount)
 	if err != nil {
 		return nil, err
 	}
+	request.ChargeType = "preauth"
+
+	response, _, err := r.chargeCard(ctx, "PreauthorizeCard", request, chargeData)
+
+	return response, err
+}
 
-	return response, nil
+// PreauthorizeCardTyped : Typed version of PreauthorizeCard
+func (r Rave) PreauthorizeCardTyped(ctx context.Context, request CardChargeRequest) (*ChargeResponse, error) {
+	request.ChargeType = "preauth"
+
+	_, chargeResponse, err := r.chargeCard(ctx, "PreauthorizeCardTyped", request, nil)
+
+	return chargeResponse, err
 }
 
 // Capture : Capture a preauthorized transaction
 func (r Rave) Capture(data map[string]interface{}) ([]byte, error) {
-	err := checkRequiredParameters(data, []string{"flwRef"})
+	return r.CaptureContext(context.Background(), data)
+}
+
+// CaptureContext : Same as Capture but honors the cancellation and deadline of ctx
+func (r Rave) CaptureContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	request := CaptureRequest{}
+	err := mapToRequest(data, &request, &request.Amount)
 	if err != nil {
 		return nil, err
 	}
 
-	data["SECKEY"] = r.GetSecretKey()
-	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/capture"
+	response, _, err := r.capture(ctx, "Capture", request, data)
 
-	response, err := MakePostRequest(URL, data)
+	return response, err
+}
+
+// CaptureTyped : Typed version of Capture.
+// Set Amount to capture less than the preauthorized amount, the rest is released to the customer.
+func (r Rave) CaptureTyped(ctx context.Context, request CaptureRequest) (*CaptureResponse, error) {
+	_, chargeResponse, err := r.capture(ctx, "CaptureTyped", request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
+	// the charged amount can include fees, both amounts are based on the requested and authorized
+	// amounts so the captured and remaining amounts add up to the authorized amount
+	captureResponse := &CaptureResponse{ChargeResponse: *chargeResponse}
+	chargeData := captureResponse.Data
+	captureResponse.CapturedAmount = firstNonZero(request.Amount, chargeData.Amount)
+	if !request.Amount.IsZero() && !chargeData.Amount.IsZero() {
+		captureResponse.RemainingAmount, err = remainingAmount(chargeData.Amount, request.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return captureResponse, nil
+}
+
+// capture : Sends a capture request for a preauthorized transaction
+func (r Rave) capture(
+	ctx context.Context, methodName string, request CaptureRequest, extra map[string]interface{},
+) ([]byte, *ChargeResponse, error) {
+	// an unset amount captures the whole hold, zero amounts with a currency would be sent as "0.00"
+	if request.Amount != (Money{}) && request.Amount.Minor <= 0 {
+		return nil, nil, fmt.Errorf("%w: can't capture %s", ErrInvalidAmount, request.Amount)
+	}
+
+	data, err := requestPayload(request, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkRequiredParameters(methodName, data, []string{"flwRef"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data["SECKEY"] = secretKey
+	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/capture"
+
+	response, err := r.makePostRequest(ctx, URL, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chargeResponse := &ChargeResponse{}
+	err = decodeResponse(response, chargeResponse)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return response, chargeResponse, nil
 }
 
 // RefundOrVoidPreauth : Refund or void a captured amount
 func (r Rave) RefundOrVoidPreauth(data map[string]interface{}) ([]byte, error) {
-	err := checkRequiredParameters(data, []string{"ref", "action"})
+	return r.RefundOrVoidPreauthContext(context.Background(), data)
+}
 
-	data["SECKEY"] = r.GetSecretKey()
-	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/refundorvoid"
+// RefundOrVoidPreauthContext : Same as RefundOrVoidPreauth but honors the cancellation and deadline of ctx
+func (r Rave) RefundOrVoidPreauthContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	request := RefundOrVoidRequest{}
+	err := mapToRequest(data, &request, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	response, err := MakePostRequest(URL, data)
+	response, _, err := r.refundOrVoidPreauth(ctx, "RefundOrVoidPreauth", request, data)
+
+	return response, err
+}
+
+// RefundOrVoidPreauthTyped : Typed version of RefundOrVoidPreauth.
+// The transaction is looked up after the refund or void to report the amount that's left,
+// the amounts are left unset if the lookup fails since the refund or void was still made.
+func (r Rave) RefundOrVoidPreauthTyped(ctx context.Context, request RefundOrVoidRequest) (*RefundOrVoidResponse, error) {
+	_, refundOrVoidResponse, err := r.refundOrVoidPreauth(ctx, "RefundOrVoidPreauthTyped", request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
+	// the captured amount or the amount held on the card if it wasn't captured
+	released := refundOrVoidResponse.Data.Data.Amount
+	_, transaction, lookupErr := r.transactionStatus(ctx, VerifyRequest{FlwRef: request.Ref, Normalize: "1"})
+	if lookupErr == nil {
+		held := firstNonZero(transaction.Data.ChargedAmount, transaction.Data.Amount)
+		released = firstNonZero(released, held)
+
+		if remaining, err := remainingAmount(held, released); err == nil {
+			refundOrVoidResponse.RemainingAmount = remaining
+		}
+	}
+
+	if request.Action == "void" {
+		refundOrVoidResponse.VoidedAmount = released
+	} else {
+		refundOrVoidResponse.RefundedAmount = released
+	}
+
+	return refundOrVoidResponse, nil
+}
+
+// refundOrVoidPreauth : Sends a refund or void request for a preauthorized transaction,
+// the action must be "void" or "refund"
+func (r Rave) refundOrVoidPreauth(
+	ctx context.Context, methodName string, request RefundOrVoidRequest, extra map[string]interface{},
+) ([]byte, *RefundOrVoidResponse, error) {
+	data, err := requestPayload(request, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkRequiredParameters(methodName, data, []string{"ref", "action"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if request.Action != "void" && request.Action != "refund" {
+		return nil, nil, fmt.Errorf("%w: got \"%s\"", ErrInvalidPreauthAction, request.Action)
+	}
+
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data["SECKEY"] = secretKey
+	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/refundorvoid"
+
+	response, err := r.makePostRequest(ctx, URL, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refundOrVoidResponse := &RefundOrVoidResponse{}
+	err = decodeResponse(response, refundOrVoidResponse)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return response, refundOrVoidResponse, nil
+}
+
+// remainingAmount : The part of amount that's left after taking out used, it's never negative
+func remainingAmount(amount, used Money) (Money, error) {
+	remaining, err := amount.Sub(used)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if remaining.Minor < 0 {
+		return Money{Currency: remaining.Currency}, nil
+	}
+
+	return remaining, nil
+}
+
+// VoidPreauth : Release the amount held on the card by a preauthorized transaction (or reverse it's capture)
+func (r Rave) VoidPreauth(ctx context.Context, flwRef string) (*RefundOrVoidResponse, error) {
+	return r.RefundOrVoidPreauthTyped(ctx, RefundOrVoidRequest{Ref: flwRef, Action: "void"})
+}
+
+// RefundPreauth : Refund the captured amount of a preauthorized transaction
+func (r Rave) RefundPreauth(ctx context.Context, flwRef string) (*RefundOrVoidResponse, error) {
+	return r.RefundOrVoidPreauthTyped(ctx, RefundOrVoidRequest{Ref: flwRef, Action: "refund"})
 }
@@ -0,0 +1,183 @@
+// Tests for preauthorized card charges
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// preauthorize : Hold ₦1052.50 on a card that doesn't need authorization
+func preauthorize(t *testing.T, client Rave, txRef string) *ChargeResponse {
+	t.Helper()
+
+	request := sessionCharge(ravetest.NoAuthCard)
+	request.TxRef = txRef
+
+	charge, err := client.PreauthorizeCardTyped(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, charge.Data.Status, ravetest.StatusPendingCapture)
+
+	return charge
+}
+
+// Partial captures should report the captured amount and the amount released to the customer
+func TestPartialCapture(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	charge := preauthorize(t, client, "preauth-partial")
+
+	_, err := client.CaptureTyped(ctx, CaptureRequest{FlwRef: charge.Data.FlwRef, Amount: MustMoney("-1", "NGN")})
+	if !errors.Is(err, ErrInvalidAmount) || !IsValidationError(err) {
+		t.Fatalf("Expected ErrInvalidAmount got %v", err)
+	}
+
+	// a zero amount with a currency would be sent as "0.00"
+	_, err = client.CaptureTyped(ctx, CaptureRequest{FlwRef: charge.Data.FlwRef, Amount: MustMoney("0", "NGN")})
+	if !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("Expected ErrInvalidAmount got %v", err)
+	}
+
+	// more than the preauthorized amount
+	_, err = client.CaptureTyped(ctx, CaptureRequest{FlwRef: charge.Data.FlwRef, Amount: MustMoney("2000", "NGN")})
+	if err == nil {
+		t.Fatal("Expected the capture to be rejected")
+	}
+
+	captured, err := client.CaptureTyped(ctx, CaptureRequest{FlwRef: charge.Data.FlwRef, Amount: MustMoney("1000", "NGN")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, captured.Data.Status, ravetest.StatusSuccessful)
+	assertEqual(t, captured.CapturedAmount, MustMoney("1000", "NGN"))
+	assertEqual(t, captured.RemainingAmount, MustMoney("52.50", "NGN"))
+
+	refunded, err := client.RefundPreauth(ctx, charge.Data.FlwRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, refunded.Data.Data.ResponseCode, "00")
+	assertEqual(t, refunded.RefundedAmount, MustMoney("1000", "NGN"))
+	assertEqual(t, refunded.VoidedAmount.IsZero(), true)
+	assertEqual(t, refunded.RemainingAmount, MustMoney("0", "NGN"))
+
+	transaction, _ := server.Transaction(charge.Data.FlwRef)
+	assertEqual(t, transaction.Status, ravetest.StatusRefunded)
+}
+
+// Voiding a hold should release the whole preauthorized amount
+func TestVoidPreauth(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	charge := preauthorize(t, client, "preauth-void")
+
+	voided, err := client.VoidPreauth(ctx, charge.Data.FlwRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, voided.VoidedAmount, MustMoney("1052.50", "NGN"))
+	assertEqual(t, voided.RemainingAmount, MustMoney("0", "NGN"))
+
+	transaction, _ := server.Transaction(charge.Data.FlwRef)
+	assertEqual(t, transaction.Status, ravetest.StatusVoided)
+
+	// the hold is released even if the transaction can't be looked up afterwards
+	failedLookup := preauthorize(t, client, "preauth-void-lookup")
+	server.Script(ravetest.VerifyPath, ravetest.ServerError())
+
+	voided, err = client.VoidPreauth(ctx, failedLookup.Data.FlwRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, voided.VoidedAmount, MustMoney("1052.50", "NGN"))
+
+	transaction, _ = server.Transaction(failedLookup.Data.FlwRef)
+	assertEqual(t, transaction.Status, ravetest.StatusVoided)
+
+	// a voided transaction can't be refunded
+	if _, err := client.RefundPreauth(ctx, charge.Data.FlwRef); err == nil {
+		t.Fatal("Expected the refund to be rejected")
+	}
+}
+
+// Actions other than "void" and "refund" shouldn't be sent to Rave
+func TestRefundOrVoidPreauthAction(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	_, err := client.RefundOrVoidPreauth(map[string]interface{}{"ref": "FLW-MOCK"})
+	assertEqual(t, err.Error(), "\"action\" is a required parameter for \"RefundOrVoidPreauth\"")
+
+	_, err = client.RefundOrVoidPreauth(map[string]interface{}{"ref": "FLW-MOCK", "action": "capture"})
+	if !errors.Is(err, ErrInvalidPreauthAction) || !IsValidationError(err) {
+		t.Errorf("Expected ErrInvalidPreauthAction got %v", err)
+	}
+
+	_, err = client.RefundOrVoidPreauthTyped(context.Background(), RefundOrVoidRequest{Ref: "FLW-MOCK", Action: "Void"})
+	if !errors.Is(err, ErrInvalidPreauthAction) {
+		t.Errorf("Expected ErrInvalidPreauthAction got %v", err)
+	}
+
+	assertEqual(t, server.Requests(ravetest.RefundOrVoidPath), 0)
+}
+
+// The remaining amount should never be negative, e.g when the charged amount includes fees
+func TestRemainingAmount(t *testing.T) {
+	t.Parallel()
+
+	remaining, err := remainingAmount(MustMoney("1000", "NGN"), MustMoney("1052.50", "NGN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, remaining, MustMoney("0", "NGN"))
+
+	remaining, _ = remainingAmount(MustMoney("1052.50", "NGN"), MustMoney("1000", "NGN"))
+	assertEqual(t, remaining, MustMoney("52.50", "NGN"))
+}
+
+// The captured and remaining amounts should add up to the authorized amount when Rave charges fees
+func TestCaptureAmountsWithFees(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	server.Script(ravetest.CapturePath, ravetest.Response{
+		StatusCode: 200,
+		Body: `{"status": "success", "message": "Capture complete", "data": {"flwRef": "FLW-PREAUTH", ` +
+			`"status": "successful", "amount": 1052.50, "charged_amount": 1014, "currency": "NGN"}}`,
+	})
+
+	captured, err := client.CaptureTyped(
+		context.Background(), CaptureRequest{FlwRef: "FLW-PREAUTH", Amount: MustMoney("1000", "NGN")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, captured.Data.ChargedAmount, MustMoney("1014", "NGN"))
+	assertEqual(t, captured.CapturedAmount, MustMoney("1000", "NGN"))
+	assertEqual(t, captured.RemainingAmount, MustMoney("52.50", "NGN"))
+}
@@ -1,10 +1,22 @@
 package rave
 
 import (
-	"log"
+	"net/http"
 	"os"
 )
 
+// default URLs of Rave's API
+const (
+	defaultLiveURL = "https://api.ravepay.co"
+	defaultTestURL = "http://flw-pms-dev.eu-west-1.elasticbeanstalk.com"
+)
+
+// DefaultUserAgent : User agent sent with every request unless WithUserAgent is used
+const DefaultUserAgent = "go-rave"
+
+// defaultHTTPClient : Used by clients created without WithHTTPClient
+var defaultHTTPClient = &http.Client{}
+
 // Rave : Base Rave type
 type Rave struct {
 	Live    bool
@@ -13,6 +25,61 @@ type Rave struct {
 
 	publicKey string
 	secretKey string
+
+	httpClient  *http.Client
+	userAgent   string
+	retryPolicy RetryPolicy
+
+	verificationRules []VerificationRule
+	checkAccountNames bool
+
+	encrypters *encrypterCache
+	banks      *bankCache
+}
+
+// Option : Configures a Rave client created with NewClient
+type Option func(*Rave)
+
+// WithKeys : Use the given keys instead of "RAVE_PUBLICKEY" and "RAVE_SECKEY"
+func WithKeys(publicKey, secretKey string) Option {
+	return func(r *Rave) {
+		r.publicKey = publicKey
+		r.secretKey = secretKey
+	}
+}
+
+// WithHTTPClient : Use a custom http.Client (timeouts, proxies, TLS config) for every request
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *Rave) {
+		r.httpClient = client
+	}
+}
+
+// WithBaseURLs : Override the live and test base URLs, empty values keep the defaults
+func WithBaseURLs(liveURL, testURL string) Option {
+	return func(r *Rave) {
+		if liveURL != "" {
+			r.liveURL = liveURL
+		}
+
+		if testURL != "" {
+			r.testURL = testURL
+		}
+	}
+}
+
+// WithLive : Set the live status of the client
+func WithLive(live bool) Option {
+	return func(r *Rave) {
+		r.Live = live
+	}
+}
+
+// WithUserAgent : Set the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(r *Rave) {
+		r.userAgent = userAgent
+	}
 }
 
 // getBaseURL : Returns the Correct URL based on Live status.
@@ -24,34 +91,92 @@ func (r Rave) getBaseURL() string {
 	return r.testURL
 }
 
+// getHTTPClient : Returns the http.Client used to make requests
+func (r Rave) getHTTPClient() *http.Client {
+	if r.httpClient != nil {
+		return r.httpClient
+	}
+
+	return defaultHTTPClient
+}
+
+// getUserAgent : Returns the User-Agent header sent with every request
+func (r Rave) getUserAgent() string {
+	if r.userAgent != "" {
+		return r.userAgent
+	}
+
+	return DefaultUserAgent
+}
+
 // GetPublicKey : Get Rave Public key
+// The key passed to WithKeys is used if it was set, otherwise it's read from "RAVE_PUBLICKEY".
+// An empty string is returned if the key isn't set anywhere.
 func (r Rave) GetPublicKey() string {
-	publicKey, found := os.LookupEnv("RAVE_PUBLICKEY")
-	if !found {
-		log.Fatal("You must set the \"RAVE_PUBLICKEY\" environment variable")
-	}
+	publicKey, _ := r.getPublicKey()
 
 	return publicKey
 }
 
 // GetSecretKey : Get Rave Secret key
+// The key passed to WithKeys is used if it was set, otherwise it's read from "RAVE_SECKEY".
+// An empty string is returned if the key isn't set anywhere.
 func (r Rave) GetSecretKey() string {
+	secKey, _ := r.getSecretKey()
+
+	return secKey
+}
+
+// getPublicKey : Get Rave Public key or ErrMissingPublicKey
+func (r Rave) getPublicKey() (string, error) {
+	if r.publicKey != "" {
+		return r.publicKey, nil
+	}
+
+	publicKey, found := os.LookupEnv("RAVE_PUBLICKEY")
+	if !found {
+		return "", ErrMissingPublicKey
+	}
+
+	return publicKey, nil
+}
+
+// getSecretKey : Get Rave Secret key or ErrMissingSecretKey
+func (r Rave) getSecretKey() (string, error) {
+	if r.secretKey != "" {
+		return r.secretKey, nil
+	}
+
 	secKey, found := os.LookupEnv("RAVE_SECKEY")
 	if !found {
-		log.Fatal("You must set the \"RAVE_SECKEY\" environment variable")
+		return "", ErrMissingSecretKey
 	}
 
-	return secKey
+	return secKey, nil
 }
 
-// NewRave : Constructor for Rave struct
-func NewRave() Rave {
+// NewClient : Constructor for a configurable Rave client.
+// Clients don't share any state so clients with different keys can be used
+// concurrently in the same process.
+func NewClient(opts ...Option) Rave {
 	Rave := Rave{}
-	Rave.testURL = "http://flw-pms-dev.eu-west-1.elasticbeanstalk.com"
-	Rave.liveURL = "https://api.ravepay.co"
+	Rave.testURL = defaultTestURL
+	Rave.liveURL = defaultLiveURL
+	Rave.retryPolicy = DefaultRetryPolicy
+	Rave.encrypters = &encrypterCache{}
+	Rave.banks = &bankCache{entries: map[string]cachedBanks{}, fetching: map[string]*bankFetch{}}
 
 	// default mode is development
 	Rave.Live = false
 
+	for _, opt := range opts {
+		opt(&Rave)
+	}
+
 	return Rave
 }
+
+// NewRave : Constructor for Rave struct
+func NewRave() Rave {
+	return NewClient()
+}
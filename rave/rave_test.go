@@ -35,7 +35,12 @@ func TestMain(m *testing.M) {
 func TestEncryption(t *testing.T) {
 	t.Parallel()
 
-	assertEqual(t, rave.Encrypt3Des("Hello world"), "fus4LnqrvKWXqm7wueoj2Q==")
+	encrypted, err := rave.Encrypt3Des("Hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, encrypted, "fus4LnqrvKWXqm7wueoj2Q==")
 }
 
 func TestSuggestedAuthPin(t *testing.T) {
@@ -530,7 +535,7 @@ func TestVerifyTransaction(t *testing.T) {
 	// Verify the transaction
 	transaction = map[string]interface{}{
 		"flw_ref": transactionReference, "normalize": "1",
-		"currency": currency, "amount": "1000",
+		"currency": currency, "amount": "300",
 	}
 	_, err := rave.VerifyTransaction(transaction)
 	if err != nil {
@@ -671,7 +676,10 @@ func TestCalculateIntegrityCheckSum(t *testing.T) {
 		panic(err)
 	}
 
-	integrityChecksum := rave.CalculateIntegrityCheckSum(data)
+	integrityChecksum, err := rave.CalculateIntegrityCheckSum(data)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	assertEqual(t, integrityChecksum, "a14ac4eba0902e8fd6b5fdf542f46d6efc18885a63c3d5f100c26715c7c8d8f4")
 
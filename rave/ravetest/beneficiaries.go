@@ -0,0 +1,220 @@
+// Implements the transfer beneficiary and account resolution endpoints
+
+package ravetest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Paths of the beneficiary and account resolution endpoints
+const (
+	BeneficiaryPath       = "/v2/gpx/transfers/beneficiaries/create"
+	BeneficiariesPath     = "/v2/gpx/transfers/beneficiaries"
+	DeleteBeneficiaryPath = "/v2/gpx/transfers/beneficiaries/delete"
+	ResolveAccountPath    = "/flwv3-pug/getpaidx/api/resolve_account"
+)
+
+// UnresolvableAccount : Account number that can't be resolved at any bank
+const UnresolvableAccount = "0000000000"
+
+// AccountName : Name accounts are resolved to unless SetAccountName is called
+const AccountName = "Ravetest Beneficiary"
+
+// Beneficiary : A bank account saved by the server
+type Beneficiary struct {
+	ID            int
+	AccountNumber string
+	BankCode      string
+	FullName      string
+	Created       time.Time
+}
+
+// SetAccountName : Set the name accountNumber is resolved to at every bank
+func (s *Server) SetAccountName(accountNumber, name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.accountNames[accountNumber] = name
+}
+
+// accountName : Name of the owner of accountNumber, the caller must hold the lock
+func (s *Server) accountName(accountNumber string) string {
+	if name, ok := s.accountNames[accountNumber]; ok {
+		return name
+	}
+
+	return AccountName
+}
+
+// Beneficiaries : Get a copy of every beneficiary that wasn't deleted
+func (s *Server) Beneficiaries() []Beneficiary {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	beneficiaries := []Beneficiary{}
+	for _, beneficiary := range s.beneficiaries {
+		beneficiaries = append(beneficiaries, *beneficiary)
+	}
+
+	return beneficiaries
+}
+
+// findBeneficiary : Find a beneficiary by ID, the caller must hold the lock
+func (s *Server) findBeneficiary(id string) (int, *Beneficiary) {
+	for i, beneficiary := range s.beneficiaries {
+		if strconv.Itoa(beneficiary.ID) == id {
+			return i, beneficiary
+		}
+	}
+
+	return -1, nil
+}
+
+// bankName : Name of the bank with code in Banks
+func bankName(code string) (string, bool) {
+	for _, bank := range Banks {
+		if bank["bankcode"] == code {
+			return fmt.Sprint(bank["bankname"]), true
+		}
+	}
+
+	return "", false
+}
+
+// beneficiaryData : Beneficiary in the format of the beneficiary endpoints
+func beneficiaryData(beneficiary *Beneficiary) map[string]interface{} {
+	name, _ := bankName(beneficiary.BankCode)
+
+	return map[string]interface{}{
+		"id":             beneficiary.ID,
+		"account_number": beneficiary.AccountNumber,
+		"bank_code":      beneficiary.BankCode,
+		"bank_name":      name,
+		"fullname":       beneficiary.FullName,
+		"date_created":   beneficiary.Created.UTC().Format(time.RFC3339),
+	}
+}
+
+// handleResolveAccount : Resolve the name of an account, UnresolvableAccount and unknown banks aren't resolved
+func (s *Server) handleResolveAccount(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "PBFPubKey")
+	if !ok {
+		return
+	}
+
+	if !checkFields(w, body, "recipientaccount", "destbankcode") {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	accountNumber := stringValue(body, "recipientaccount")
+	_, known := bankName(stringValue(body, "destbankcode"))
+
+	// Rave reports accounts that can't be resolved in a successful response
+	account := map[string]interface{}{
+		"responsecode": "RR", "responsemessage": "Sorry, recipient account could not be validated. Please try again",
+		"accountnumber": accountNumber, "accountname": nil,
+	}
+	if known && accountNumber != UnresolvableAccount {
+		account["responsecode"], account["responsemessage"] = "00", "Approved"
+		account["accountname"] = s.accountName(accountNumber)
+	}
+
+	success(w, "ACCOUNT RESOLVED", map[string]interface{}{"status": "success", "data": account})
+}
+
+// handleBeneficiary : Create a beneficiary
+func (s *Server) handleBeneficiary(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "seckey")
+	if !ok {
+		return
+	}
+
+	if !checkFields(w, body, "account_number", "account_bank") {
+		return
+	}
+
+	accountNumber, bankCode := stringValue(body, "account_number"), stringValue(body, "account_bank")
+	if _, known := bankName(bankCode); !known || accountNumber == UnresolvableAccount {
+		fail(w, http.StatusBadRequest, "Account could not be resolved")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	beneficiary := &Beneficiary{
+		ID: s.nextID, AccountNumber: accountNumber, BankCode: bankCode, FullName: s.accountName(accountNumber),
+		Created: time.Now(),
+	}
+	s.nextID++
+	s.beneficiaries = append(s.beneficiaries, beneficiary)
+
+	success(w, "BENEFICIARY-CREATED", beneficiaryData(beneficiary))
+}
+
+// handleBeneficiaries : List beneficiaries a page at a time
+func (s *Server) handleBeneficiaries(w http.ResponseWriter, req *http.Request) {
+	query, ok := s.decodeQuery(w, req)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	total := len(s.beneficiaries)
+	start, end := (page-1)*payoutsPerPage, page*payoutsPerPage
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	beneficiaries := []interface{}{}
+	for _, beneficiary := range s.beneficiaries[start:end] {
+		beneficiaries = append(beneficiaries, beneficiaryData(beneficiary))
+	}
+
+	success(w, "PAYOUT-BENEFICIARIES", map[string]interface{}{
+		"page_info": map[string]interface{}{
+			"total": total, "current_page": page, "total_pages": (total + payoutsPerPage - 1) / payoutsPerPage,
+		},
+		"payout_beneficiaries": beneficiaries,
+	})
+}
+
+// handleDeleteBeneficiary : Delete the beneficiary with "id"
+func (s *Server) handleDeleteBeneficiary(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "seckey")
+	if !ok {
+		return
+	}
+
+	if !checkFields(w, body, "id") {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i, beneficiary := s.findBeneficiary(stringValue(body, "id"))
+	if beneficiary == nil {
+		fail(w, http.StatusNotFound, "Beneficiary not found")
+		return
+	}
+	s.beneficiaries = append(s.beneficiaries[:i], s.beneficiaries[i+1:]...)
+
+	success(w, "SUCCESSFUL", "Completed deleting beneficiary")
+}
@@ -0,0 +1,59 @@
+// Implements the 3DES decryption used to read the "client" payload of charges
+
+package ravetest
+
+import (
+	"bytes"
+	"crypto/des"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// encryptionKey : Derive the 3DES key from a secret key the same way Rave does
+func encryptionKey(secretKey string) ([]byte, error) {
+	hashedSecretKey := md5.Sum([]byte(secretKey))
+	secretKeyAdjusted := strings.Replace(secretKey, "FLWSECK-", "", 1)
+	if len(secretKeyAdjusted) < 12 {
+		return nil, errors.New("secret key is too short")
+	}
+
+	return []byte(secretKeyAdjusted[:12] + hex.EncodeToString(hashedSecretKey[len(hashedSecretKey)-6:])), nil
+}
+
+// decrypt3Des : Decrypt a base64 encoded payload encrypted with 3DES (ECB mode and PKCS5 padding)
+func decrypt3Des(secretKey string, payload string) ([]byte, error) {
+	key, err := encryptionKey(secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := block.BlockSize()
+	if len(encrypted) == 0 || len(encrypted)%bs != 0 {
+		return nil, errors.New("payload is not a multiple of the block size")
+	}
+
+	decrypted := make([]byte, len(encrypted))
+	for i := 0; i < len(encrypted); i += bs {
+		block.Decrypt(decrypted[i:i+bs], encrypted[i:i+bs])
+	}
+
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > bs || !bytes.HasSuffix(decrypted, bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("invalid padding")
+	}
+
+	return decrypted[:len(decrypted)-padding], nil
+}
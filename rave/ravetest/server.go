@@ -0,0 +1,1233 @@
+/*
+Package ravetest provides a fake Rave server for offline integration testing.
+
+The server implements the charge, validation, verification, preauthorization,
+refund, fee, bank, tokenized charge, transfer and balance endpoints used by the rave package, keeps track of every
+transaction and transfer (payout) it creates and moves them through the same states Rave does
+(PIN -> OTP, 3DSecure and internet banking redirects, preauth -> (partial) capture -> void/refund, mobile
+money, USSD, Mcash and bank transfer charges pending until ApprovePayment is
+called).
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := rave.NewClient(
+		rave.WithKeys(server.PublicKey, server.SecretKey),
+		rave.WithBaseURLs("", server.URL),
+	)
+
+Declines, timeouts and malformed bodies can be scripted with Script.
+*/
+package ravetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default keys used by NewServer
+const (
+	DefaultPublicKey = "FLWPUBK-ravetest0123456789abcdef01234567-X"
+	DefaultSecretKey = "FLWSECK-ravetest0123456789abcdef01234567-X"
+)
+
+// ValidOTP : The only OTP accepted by the validation endpoints
+const ValidOTP = "12345"
+
+// Paths of the endpoints implemented by the server
+const (
+	ChargePath         = "/flwv3-pug/getpaidx/api/charge"
+	ValidateChargePath = "/flwv3-pug/getpaidx/api/validatecharge"
+	ValidatePath       = "/flwv3-pug/getpaidx/api/validate"
+	VerifyPath         = "/flwv3-pug/getpaidx/api/verify"
+	XrequeryPath       = "/flwv3-pug/getpaidx/api/xrequery"
+	CapturePath        = "/flwv3-pug/getpaidx/api/capture"
+	RefundOrVoidPath   = "/flwv3-pug/getpaidx/api/refundorvoid"
+	RefundPath         = "/gpx/merchant/transactions/refund"
+	FeePath            = "/flwv3-pug/getpaidx/api/fee"
+	BanksPath          = "/flwv3-pug/getpaidx/api/flwpbf-banks.js"
+	TokenChargePath    = "/flwv3-pug/getpaidx/api/tokenized/charge"
+
+	// AuthPath : Page the customer is sent to for 3DSecure, followed by the flwRef
+	AuthPath = "/ravetest/auth/"
+)
+
+// Scenario : How the server responds to a card charge
+type Scenario int
+
+// Card charge scenarios
+const (
+	// ScenarioPIN : Rave suggests "PIN", the charge is then validated with an OTP
+	ScenarioPIN Scenario = iota
+
+	// Scenario3DSecure : The customer must visit the auth URL to complete the charge
+	Scenario3DSecure
+
+	// ScenarioNoAuth : The charge completes immediately
+	ScenarioNoAuth
+
+	// ScenarioDeclined : The charge is declined as fraudulent
+	ScenarioDeclined
+
+	// ScenarioNoAuthInternational : Rave suggests "NOAUTH_INTERNATIONAL", the
+	// charge completes once it's sent again with the billing address
+	ScenarioNoAuthInternational
+
+	// ScenarioAVS : Rave suggests "AVS_VBVSECURECODE", once the charge is sent
+	// again with the billing address the customer must visit the auth URL
+	ScenarioAVS
+)
+
+// mobileMoneyTypes : payment_type of mobile money charges and the flag Rave expects with it
+var mobileMoneyTypes = map[string]string{
+	"mobilemoneygh":     "is_mobile_money_gh",
+	"mpesa":             "is_mpesa",
+	"mobilemoneyuganda": "is_mobile_money_ug",
+	"mobilemoneyzambia": "is_mobile_money_ug",
+	"mobilemoneyfranco": "is_mobile_money_franco",
+}
+
+// approvedByCustomer : Auth models of charges the customer completes on their phone or with a transfer
+var approvedByCustomer = map[string]bool{"MOBILEMONEY": true, "USSD": true, "MCASH": true, "BANKTRANSFER": true}
+
+// DefaultTransferExpiry : How long the accounts of bank transfer charges stay valid unless SetTransferExpiry is called
+const DefaultTransferExpiry = time.Hour
+
+// Test cards with a default scenario, other cards use ScenarioPIN
+const (
+	PinCard          = "5438898014560229"
+	ThreeDSecureCard = "4187427415564246"
+	NoAuthCard       = "4242424242424242"
+	DeclinedCard     = "5590131743294314"
+
+	InternationalCard = "4556052704172643"
+	AVSCard           = "4000000000000002"
+)
+
+// InternetBankingAccount : Account number whose charges are authorized on the
+// bank's page (the auth URL) instead of with an OTP
+const InternetBankingAccount = "0690000032"
+
+// redirectAuthModels : Auth models of charges the customer authorizes at the auth URL
+var redirectAuthModels = map[string]bool{"VBVSECURECODE": true, "INTERNETBANKING": true, "ACH": true}
+
+// BillingFields : Fields required by ScenarioNoAuthInternational and ScenarioAVS
+var BillingFields = []string{"billingzip", "billingcity", "billingaddress", "billingstate", "billingcountry"}
+
+// Transaction statuses
+const (
+	StatusPendingValidation = "success-pending-validation"
+	StatusPendingCapture    = "pending-capture"
+	StatusSuccessful        = "successful"
+	StatusVoided            = "voided"
+	StatusRefunded          = "refunded"
+)
+
+// Transaction : A transaction created by the server
+type Transaction struct {
+	ID          int
+	FlwRef      string
+	TxRef       string
+	Amount      json.Number
+	Currency    string
+	Status      string
+	AuthModel   string
+	ChargeType  string
+	PaymentType string
+	Email       string
+	RedirectURL string
+
+	// Amount taken by a partial capture, the whole amount is charged when it's empty
+	CapturedAmount json.Number
+
+	// embed_token that charges the same card again, only set for card charges
+	EmbedToken string
+
+	// Decrypted charge payload
+	Payload map[string]interface{}
+}
+
+// Response : A scripted response
+type Response struct {
+	StatusCode int
+	Body       string
+
+	// Wait before responding, the wait ends early if the client gives up
+	Delay time.Duration
+
+	// Handle the request normally but send this response instead
+	Handle bool
+}
+
+// Decline : Scripted response for a declined charge
+func Decline(message string) Response {
+	body, _ := json.Marshal(map[string]interface{}{
+		"status": "error", "message": message, "data": map[string]interface{}{"code": "FLW_ERR", "message": message},
+	})
+
+	return Response{StatusCode: http.StatusBadRequest, Body: string(body)}
+}
+
+// LostResponse : Scripted response for a request Rave handled but whose response never reached the client
+func LostResponse() Response {
+	return Response{StatusCode: http.StatusGatewayTimeout, Body: "Gateway Timeout", Handle: true}
+}
+
+// Timeout : Scripted response that is only sent (as a 504) after delay
+func Timeout(delay time.Duration) Response {
+	return Response{StatusCode: http.StatusGatewayTimeout, Body: "Gateway Timeout", Delay: delay}
+}
+
+// MalformedBody : Scripted response whose body isn't valid JSON
+func MalformedBody() Response {
+	return Response{StatusCode: http.StatusBadGateway, Body: "<html>502 Bad Gateway</html>"}
+}
+
+// ServerError : Scripted response for an internal server error
+func ServerError() Response {
+	return Response{
+		StatusCode: http.StatusInternalServerError,
+		Body:       `{"status": "error", "message": "Internal server error"}`,
+	}
+}
+
+// Server : Fake Rave server
+type Server struct {
+	*httptest.Server
+
+	PublicKey string
+	SecretKey string
+
+	mutex        sync.Mutex
+	nextID       int
+	transactions map[string]*Transaction
+	cards        map[string]Scenario
+	scripts      map[string][]Response
+	requests     map[string]int
+	charges      []map[string]interface{}
+	tokens       map[string]*chargeToken
+
+	// how long the accounts of bank transfer charges stay valid
+	transferExpiry time.Duration
+
+	payouts       []*Payout
+	balances      map[string]float64
+	beneficiaries []*Beneficiary
+	accountNames  map[string]string
+}
+
+// chargeToken : Card token issued after a card charge
+type chargeToken struct {
+	email   string
+	expired bool
+}
+
+// NewServer : Start a fake Rave server that uses the default keys
+func NewServer() *Server {
+	return NewServerWithKeys(DefaultPublicKey, DefaultSecretKey)
+}
+
+// NewServerWithKeys : Start a fake Rave server that only accepts the given keys
+func NewServerWithKeys(publicKey, secretKey string) *Server {
+	s := &Server{
+		PublicKey:    publicKey,
+		SecretKey:    secretKey,
+		nextID:       1,
+		transactions: map[string]*Transaction{},
+		cards: map[string]Scenario{
+			ThreeDSecureCard:  Scenario3DSecure,
+			NoAuthCard:        ScenarioNoAuth,
+			DeclinedCard:      ScenarioDeclined,
+			InternationalCard: ScenarioNoAuthInternational,
+			AVSCard:           ScenarioAVS,
+		},
+		scripts:  map[string][]Response{},
+		requests: map[string]int{},
+		tokens:   map[string]*chargeToken{},
+		balances: map[string]float64{},
+
+		accountNames: map[string]string{},
+
+		transferExpiry: DefaultTransferExpiry,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(ChargePath, s.handleCharge)
+	mux.HandleFunc(ValidateChargePath, s.handleValidateCharge)
+	mux.HandleFunc(ValidatePath, s.handleValidateAccountCharge)
+	mux.HandleFunc(VerifyPath, s.handleVerify)
+	mux.HandleFunc(XrequeryPath, s.handleXrequery)
+	mux.HandleFunc(CapturePath, s.handleCapture)
+	mux.HandleFunc(RefundOrVoidPath, s.handleRefundOrVoid)
+	mux.HandleFunc(RefundPath, s.handleRefund)
+	mux.HandleFunc(FeePath, s.handleFee)
+	mux.HandleFunc(BanksPath, s.handleBanks)
+	mux.HandleFunc(TokenChargePath, s.handleTokenCharge)
+	mux.HandleFunc(AuthPath, s.handleAuth)
+	mux.HandleFunc(TransferPath, s.handleTransfer)
+	mux.HandleFunc(BulkTransferPath, s.handleBulkTransfer)
+	mux.HandleFunc(TransfersPath, s.handleTransfers)
+	mux.HandleFunc(TransferFeePath, s.handleTransferFee)
+	mux.HandleFunc(BalancePath, s.handleBalance)
+	mux.HandleFunc(ResolveAccountPath, s.handleResolveAccount)
+	mux.HandleFunc(BeneficiaryPath, s.handleBeneficiary)
+	mux.HandleFunc(BeneficiariesPath, s.handleBeneficiaries)
+	mux.HandleFunc(DeleteBeneficiaryPath, s.handleDeleteBeneficiary)
+
+	s.Server = httptest.NewServer(s.scripted(mux))
+
+	return s
+}
+
+// SetCardScenario : Set how charges on cardNo are handled
+func (s *Server) SetCardScenario(cardNo string, scenario Scenario) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cards[cardNo] = scenario
+}
+
+// Script : Queue responses for path, each request to path gets the next response
+// in the queue instead of being handled normally until the queue is empty
+func (s *Server) Script(path string, responses ...Response) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.scripts[path] = append(s.scripts[path], responses...)
+}
+
+// Requests : Number of requests received for path (including scripted ones)
+func (s *Server) Requests(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.requests[path]
+}
+
+// Charges : Decrypted payloads of every charge received by the server
+func (s *Server) Charges() []map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]map[string]interface{}{}, s.charges...)
+}
+
+// Transaction : Get a copy of the transaction with flwRef
+func (s *Server) Transaction(flwRef string) (Transaction, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction, ok := s.transactions[flwRef]
+	if !ok {
+		return Transaction{}, false
+	}
+
+	return *transaction, true
+}
+
+// ExpireToken : Make every later charge with token fail with "Token has expired"
+func (s *Server) ExpireToken(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if chargeToken, ok := s.tokens[token]; ok {
+		chargeToken.expired = true
+	}
+}
+
+// ApprovePayment : Complete a pending mobile money, USSD, Mcash or bank transfer
+// charge as if the customer paid. It reports whether the charge was pending.
+func (s *Server) ApprovePayment(flwRef string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction := s.findTransaction(flwRef, "")
+	if transaction == nil || !approvedByCustomer[transaction.AuthModel] || transaction.Status != StatusPendingValidation {
+		return false
+	}
+
+	transaction.Status = StatusSuccessful
+
+	return true
+}
+
+// SetTransferExpiry : Set how long the accounts of later bank transfer charges stay valid
+func (s *Server) SetTransferExpiry(expiry time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.transferExpiry = expiry
+}
+
+// AuthURL : URL of the 3DSecure page for the transaction with flwRef
+func (s *Server) AuthURL(flwRef string) string {
+	return s.URL + AuthPath + flwRef
+}
+
+// scripted : Count requests and send scripted responses before calling next
+func (s *Server) scripted(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.mutex.Lock()
+		s.requests[req.URL.Path]++
+
+		queue := s.scripts[req.URL.Path]
+		if len(queue) == 0 {
+			s.mutex.Unlock()
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		response := queue[0]
+		s.scripts[req.URL.Path] = queue[1:]
+		s.mutex.Unlock()
+
+		if response.Handle {
+			next.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		if response.Delay > 0 {
+			select {
+			case <-time.After(response.Delay):
+			case <-req.Context().Done():
+				return
+			}
+		}
+
+		w.WriteHeader(response.StatusCode)
+		w.Write([]byte(response.Body))
+	})
+}
+
+// readBody : Decode a JSON request body, numbers are kept as json.Number
+func readBody(req *http.Request) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(req.Body)
+	decoder.UseNumber()
+
+	body := map[string]interface{}{}
+	err := decoder.Decode(&body)
+
+	return body, err
+}
+
+// respond : Write a JSON response
+func respond(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
+
+// success : Write a successful response
+func success(w http.ResponseWriter, message string, data interface{}) {
+	respond(w, http.StatusOK, map[string]interface{}{"status": "success", "message": message, "data": data})
+}
+
+// fail : Write an error response
+func fail(w http.ResponseWriter, statusCode int, message string) {
+	respond(w, statusCode, map[string]interface{}{
+		"status": "error", "message": message, "data": map[string]interface{}{"code": "ERR", "message": message},
+	})
+}
+
+// stringValue : Read a value of the request as a string
+func stringValue(body map[string]interface{}, key string) string {
+	value, ok := body[key]
+	if !ok || value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+// number : Convert an amount sent by the client ("300", 300 or 300.5) to json.Number
+func number(value string) json.Number {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return "0"
+	}
+
+	return json.Number(value)
+}
+
+// checkPublicKey : Fail the request if it wasn't sent with the server's public key
+func (s *Server) checkPublicKey(w http.ResponseWriter, body map[string]interface{}) bool {
+	if stringValue(body, "PBFPubKey") != s.PublicKey {
+		fail(w, http.StatusUnauthorized, "Invalid public key")
+		return false
+	}
+
+	return true
+}
+
+// checkSecretKey : Fail the request if it wasn't sent with the server's secret key
+func (s *Server) checkSecretKey(w http.ResponseWriter, body map[string]interface{}, key string) bool {
+	if stringValue(body, key) != s.SecretKey {
+		fail(w, http.StatusUnauthorized, "Invalid secret key")
+		return false
+	}
+
+	return true
+}
+
+// decodeRequest : Decode the request body and check the public or secret key
+func (s *Server) decodeRequest(w http.ResponseWriter, req *http.Request, keyField string) (map[string]interface{}, bool) {
+	if req.Method != "POST" {
+		fail(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return nil, false
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		fail(w, http.StatusBadRequest, "Invalid JSON body")
+		return nil, false
+	}
+
+	if keyField == "PBFPubKey" {
+		return body, s.checkPublicKey(w, body)
+	}
+
+	return body, s.checkSecretKey(w, body, keyField)
+}
+
+// decodeQuery : Check the secret key sent in the query string of a GET request
+func (s *Server) decodeQuery(w http.ResponseWriter, req *http.Request) (url.Values, bool) {
+	if req.Method != "GET" {
+		fail(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return nil, false
+	}
+
+	query := req.URL.Query()
+	if query.Get("seckey") != s.SecretKey {
+		fail(w, http.StatusUnauthorized, "Invalid secret key")
+		return nil, false
+	}
+
+	return query, true
+}
+
+// newTransaction : Create a transaction from a decrypted charge payload, the caller must hold the lock
+func (s *Server) newTransaction(payload map[string]interface{}, paymentType string) *Transaction {
+	transaction := &Transaction{
+		ID:          s.nextID,
+		FlwRef:      fmt.Sprintf("FLW-MOCK-%06d", s.nextID),
+		TxRef:       stringValue(payload, "txRef"),
+		Amount:      number(stringValue(payload, "amount")),
+		Currency:    stringValue(payload, "currency"),
+		ChargeType:  stringValue(payload, "charge_type"),
+		PaymentType: paymentType,
+		Email:       stringValue(payload, "email"),
+		RedirectURL: stringValue(payload, "redirect_url"),
+		Payload:     payload,
+	}
+
+	if transaction.Currency == "" {
+		transaction.Currency = "NGN"
+	}
+
+	if transaction.ChargeType == "" {
+		transaction.ChargeType = "normal"
+	}
+
+	s.nextID++
+	s.transactions[transaction.FlwRef] = transaction
+
+	return transaction
+}
+
+// findTransaction : Find a transaction by flwRef or txRef, the caller must hold the lock
+func (s *Server) findTransaction(flwRef, txRef string) *Transaction {
+	if transaction, ok := s.transactions[flwRef]; ok {
+		return transaction
+	}
+
+	if txRef == "" {
+		return nil
+	}
+
+	// return the last attempt with txRef
+	var found *Transaction
+	for _, transaction := range s.transactions {
+		if transaction.TxRef == txRef && (found == nil || transaction.ID > found.ID) {
+			found = transaction
+		}
+	}
+
+	return found
+}
+
+// chargeResponseCode : "00" for completed transactions and "02" for pending ones
+func chargeResponseCode(transaction *Transaction) string {
+	switch transaction.Status {
+	case StatusPendingValidation:
+		return "02"
+	case StatusSuccessful, StatusPendingCapture, StatusRefunded, StatusVoided:
+		return "00"
+	}
+
+	return "RR"
+}
+
+// chargedAmount : The captured amount of partially captured transactions or the whole amount
+func chargedAmount(transaction *Transaction) json.Number {
+	if transaction.CapturedAmount != "" {
+		return transaction.CapturedAmount
+	}
+
+	return transaction.Amount
+}
+
+// chargeData : Transaction details in the format of the charge endpoint
+func (s *Server) chargeData(transaction *Transaction) map[string]interface{} {
+	data := map[string]interface{}{
+		"id":                    transaction.ID,
+		"txRef":                 transaction.TxRef,
+		"flwRef":                transaction.FlwRef,
+		"redirectUrl":           transaction.RedirectURL,
+		"amount":                transaction.Amount,
+		"charged_amount":        chargedAmount(transaction),
+		"appfee":                0,
+		"merchantfee":           0,
+		"chargeResponseCode":    chargeResponseCode(transaction),
+		"chargeResponseMessage": transaction.Status,
+		"authModelUsed":         transaction.AuthModel,
+		"currency":              transaction.Currency,
+		"status":                transaction.Status,
+		"paymentType":           transaction.PaymentType,
+		"charge_type":           transaction.ChargeType,
+		"customer":              map[string]interface{}{"email": transaction.Email},
+	}
+
+	if redirectAuthModels[transaction.AuthModel] {
+		data["authurl"] = s.AuthURL(transaction.FlwRef)
+	}
+
+	if transaction.EmbedToken != "" {
+		data["chargeToken"] = map[string]interface{}{
+			"user_token": fmt.Sprintf("%05d", transaction.ID), "embed_token": transaction.EmbedToken,
+		}
+	}
+
+	return data
+}
+
+// handleCharge : Card and account charges
+func (s *Server) handleCharge(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "PBFPubKey")
+	if !ok {
+		return
+	}
+
+	if stringValue(body, "alg") != "3DES-24" {
+		fail(w, http.StatusBadRequest, "Unsupported encryption algorithm")
+		return
+	}
+
+	decrypted, err := decrypt3Des(s.SecretKey, stringValue(body, "client"))
+	if err != nil {
+		fail(w, http.StatusBadRequest, "Unable to decrypt the client payload")
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(decrypted))
+	decoder.UseNumber()
+
+	payload := map[string]interface{}{}
+	if err := decoder.Decode(&payload); err != nil {
+		fail(w, http.StatusBadRequest, "Invalid client payload")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.charges = append(s.charges, payload)
+
+	if _, ok := payload["accountnumber"]; ok {
+		s.chargeAccount(w, payload)
+		return
+	}
+
+	if _, ok := mobileMoneyTypes[stringValue(payload, "payment_type")]; ok {
+		s.chargeMobileMoney(w, payload)
+		return
+	}
+
+	switch stringValue(payload, "payment_type") {
+	case "ussd":
+		s.chargeUSSD(w, payload)
+		return
+	case "mcash":
+		s.chargeMcash(w, payload)
+		return
+	case "banktransfer":
+		s.chargeBankTransfer(w, payload)
+		return
+	}
+
+	s.chargeCard(w, payload)
+}
+
+// chargeCard : Handle a card charge, the caller must hold the lock
+func (s *Server) chargeCard(w http.ResponseWriter, payload map[string]interface{}) {
+	scenario := s.cards[stringValue(payload, "cardno")]
+	preauth := stringValue(payload, "charge_type") == "preauth"
+
+	switch scenario {
+	case ScenarioDeclined:
+		respond(w, http.StatusBadRequest, map[string]interface{}{
+			"status": "error", "message": "Fraudulent. Transaction",
+			"data": map[string]interface{}{"code": "FLW_ERR", "message": "Fraudulent. Transaction"},
+		})
+		return
+
+	case ScenarioPIN:
+		if !strings.EqualFold(stringValue(payload, "suggested_auth"), "PIN") {
+			success(w, "AUTH_SUGGESTION", map[string]interface{}{"suggested_auth": "PIN"})
+			return
+		}
+
+		if stringValue(payload, "pin") == "" {
+			fail(w, http.StatusBadRequest, "Pin is required for this card")
+			return
+		}
+
+	case ScenarioNoAuthInternational, ScenarioAVS:
+		suggestedAuth := "NOAUTH_INTERNATIONAL"
+		if scenario == ScenarioAVS {
+			suggestedAuth = "AVS_VBVSECURECODE"
+		}
+
+		if !strings.EqualFold(stringValue(payload, "suggested_auth"), suggestedAuth) {
+			success(w, "AUTH_SUGGESTION", map[string]interface{}{"suggested_auth": suggestedAuth})
+			return
+		}
+
+		for _, field := range BillingFields {
+			if stringValue(payload, field) == "" {
+				fail(w, http.StatusBadRequest, field+" is required for this card")
+				return
+			}
+		}
+	}
+
+	transaction := s.newTransaction(payload, "card")
+	transaction.EmbedToken = fmt.Sprintf("flw-t0-ravetest-%06d", transaction.ID)
+	s.tokens[transaction.EmbedToken] = &chargeToken{email: transaction.Email}
+
+	switch {
+	case scenario == Scenario3DSecure:
+		transaction.AuthModel = "VBVSECURECODE"
+		transaction.Status = StatusPendingValidation
+	case scenario == ScenarioAVS:
+		transaction.AuthModel = "VBVSECURECODE"
+		transaction.Status = StatusPendingValidation
+	case scenario == ScenarioNoAuth:
+		transaction.AuthModel = "NOAUTH"
+		transaction.Status = StatusSuccessful
+	case scenario == ScenarioNoAuthInternational:
+		transaction.AuthModel = "NOAUTH_INTERNATIONAL"
+		transaction.Status = StatusSuccessful
+	default:
+		transaction.AuthModel = "PIN"
+		transaction.Status = StatusPendingValidation
+	}
+
+	// preauthorized cards are held for capture instead of being validated
+	if preauth && transaction.AuthModel != "VBVSECURECODE" {
+		transaction.Status = StatusPendingCapture
+	}
+
+	success(w, "V-COMP", s.chargeData(transaction))
+}
+
+// chargeAccount : Handle an account charge, the caller must hold the lock.
+// US (ACH) charges are authorized at the auth URL, South African ones with an OTP.
+func (s *Server) chargeAccount(w http.ResponseWriter, payload map[string]interface{}) {
+	switch strings.ToUpper(stringValue(payload, "country")) {
+	case "US":
+		if !checkFields(w, payload, "is_us_bank_charge", "routingnumber", "redirect_url") {
+			return
+		}
+
+		transaction := s.newTransaction(payload, "account")
+		transaction.AuthModel = "ACH"
+		transaction.Status = StatusPendingValidation
+
+		success(w, "V-COMP", s.chargeData(transaction))
+		return
+
+	case "ZA":
+		if !checkFields(w, payload, "is_south_african_bank_account", "passcode") {
+			return
+		}
+	}
+
+	transaction := s.newTransaction(payload, "account")
+	transaction.AuthModel = "AUTH"
+	transaction.Status = StatusPendingValidation
+
+	if stringValue(payload, "accountnumber") == InternetBankingAccount {
+		transaction.AuthModel = "INTERNETBANKING"
+		success(w, "V-COMP", s.chargeData(transaction))
+		return
+	}
+
+	data := s.chargeData(transaction)
+	data["validateInstructions"] = "Please validate with the OTP sent to your mobile or email"
+
+	success(w, "V-COMP", data)
+}
+
+// chargeMobileMoney : Handle a mobile money charge, the caller must hold the lock
+func (s *Server) chargeMobileMoney(w http.ResponseWriter, payload map[string]interface{}) {
+	paymentType := stringValue(payload, "payment_type")
+
+	if stringValue(payload, mobileMoneyTypes[paymentType]) != "1" {
+		fail(w, http.StatusBadRequest, mobileMoneyTypes[paymentType]+" is required for "+paymentType)
+		return
+	}
+
+	for _, field := range []string{"phonenumber", "orderRef"} {
+		if stringValue(payload, field) == "" {
+			fail(w, http.StatusBadRequest, field+" is required for mobile money charges")
+			return
+		}
+	}
+
+	if paymentType == "mobilemoneygh" && stringValue(payload, "network") == "" {
+		fail(w, http.StatusBadRequest, "network is required for mobile money charges")
+		return
+	}
+
+	if strings.EqualFold(stringValue(payload, "network"), "VODAFONE") && stringValue(payload, "voucher") == "" {
+		fail(w, http.StatusBadRequest, "voucher is required for Vodafone mobile money charges")
+		return
+	}
+
+	transaction := s.newTransaction(payload, paymentType)
+	transaction.AuthModel = "MOBILEMONEY"
+	transaction.Status = StatusPendingValidation
+
+	data := s.chargeData(transaction)
+	data["validateInstructions"] = "Please approve the payment on your phone"
+
+	success(w, "V-COMP", data)
+}
+
+// checkFields : Fail the request if any of fields is missing from the payload
+func checkFields(w http.ResponseWriter, payload map[string]interface{}, fields ...string) bool {
+	for _, field := range fields {
+		if stringValue(payload, field) == "" {
+			fail(w, http.StatusBadRequest, field+" is required")
+			return false
+		}
+	}
+
+	return true
+}
+
+// chargeUSSD : Handle a USSD charge, the caller must hold the lock
+func (s *Server) chargeUSSD(w http.ResponseWriter, payload map[string]interface{}) {
+	if !checkFields(w, payload, "is_ussd", "accountbank", "orderRef") {
+		return
+	}
+
+	transaction := s.newTransaction(payload, "ussd")
+	transaction.AuthModel = "USSD"
+	transaction.Status = StatusPendingValidation
+
+	// USSD strings only contain whole amounts
+	amount := strings.SplitN(string(transaction.Amount), ".", 2)[0]
+	dialString := fmt.Sprintf("*737*50*%s*%d#", amount, transaction.ID)
+
+	data := s.chargeData(transaction)
+	data["note"] = dialString
+	data["validateInstructions"] = "Please dial " + dialString + " to complete this transaction"
+
+	success(w, "V-COMP", data)
+}
+
+// chargeMcash : Handle an Mcash charge, the caller must hold the lock
+func (s *Server) chargeMcash(w http.ResponseWriter, payload map[string]interface{}) {
+	if !checkFields(w, payload, "is_mcash", "orderRef") {
+		return
+	}
+
+	transaction := s.newTransaction(payload, "mcash")
+	transaction.AuthModel = "MCASH"
+	transaction.Status = StatusPendingValidation
+
+	paymentCode := fmt.Sprintf("%06d", transaction.ID)
+
+	data := s.chargeData(transaction)
+	data["paymentCode"] = paymentCode
+	data["validateInstructions"] = "Please pay with the code " + paymentCode + " to complete this transaction"
+
+	success(w, "V-COMP", data)
+}
+
+// chargeBankTransfer : Handle a bank transfer charge, the caller must hold the lock.
+// The response has the format of Rave's bank transfer charges instead of chargeData.
+func (s *Server) chargeBankTransfer(w http.ResponseWriter, payload map[string]interface{}) {
+	if !checkFields(w, payload, "is_bank_transfer") {
+		return
+	}
+
+	transaction := s.newTransaction(payload, "banktransfer")
+	transaction.AuthModel = "BANKTRANSFER"
+	transaction.Status = StatusPendingValidation
+
+	success(w, "Transaction in progress", map[string]interface{}{
+		"response_code":    "02",
+		"response_message": "Transaction in progress",
+		"flw_reference":    transaction.FlwRef,
+		"accountnumber":    fmt.Sprintf("78%08d", transaction.ID),
+		"accountstatus":    "ACTIVE",
+		"frequency":        1,
+		"bankname":         "RAVETEST BANK",
+		"expiry_date":      time.Now().Add(s.transferExpiry).UTC().Format(time.RFC3339Nano),
+		"note":             "Please make a bank transfer to RAVETEST BANK",
+		"amount":           transaction.Amount,
+	})
+}
+
+// validateOTP : Validate a pending transaction with an OTP, the caller must hold the lock
+func (s *Server) validateOTP(w http.ResponseWriter, flwRef, otp string) *Transaction {
+	transaction := s.findTransaction(flwRef, "")
+	if transaction == nil {
+		fail(w, http.StatusBadRequest, "Transaction not found")
+		return nil
+	}
+
+	if transaction.Status != StatusPendingValidation || redirectAuthModels[transaction.AuthModel] || approvedByCustomer[transaction.AuthModel] {
+		fail(w, http.StatusBadRequest, "Transaction is not pending validation")
+		return nil
+	}
+
+	if otp != ValidOTP {
+		fail(w, http.StatusBadRequest, "Invalid OTP")
+		return nil
+	}
+
+	transaction.Status = StatusSuccessful
+
+	return transaction
+}
+
+// handleValidateCharge : Validate a card charge with an OTP
+func (s *Server) handleValidateCharge(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "PBFPubKey")
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction := s.validateOTP(w, stringValue(body, "transaction_reference"), stringValue(body, "otp"))
+	if transaction == nil {
+		return
+	}
+
+	success(w, "Charge Complete", map[string]interface{}{
+		"data": map[string]interface{}{"responsecode": "00", "responsemessage": "successful"},
+		"tx":   s.chargeData(transaction),
+	})
+}
+
+// handleValidateAccountCharge : Validate an account charge with an OTP
+func (s *Server) handleValidateAccountCharge(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "PBFPubKey")
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction := s.validateOTP(w, stringValue(body, "transactionreference"), stringValue(body, "otp"))
+	if transaction == nil {
+		return
+	}
+
+	success(w, "Charge Complete", s.chargeData(transaction))
+}
+
+// handleVerify : Verify a transaction, the response uses the "normalize=1" format
+func (s *Server) handleVerify(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "SECKEY")
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction := s.findTransaction(stringValue(body, "flw_ref"), stringValue(body, "tx_ref"))
+	if transaction == nil {
+		fail(w, http.StatusBadRequest, "No transaction found")
+		return
+	}
+
+	success(w, "Tx Fetched", map[string]interface{}{
+		"id":                   transaction.ID,
+		"flw_ref":              transaction.FlwRef,
+		"tx_ref":               transaction.TxRef,
+		"amount":               transaction.Amount,
+		"charged_amount":       chargedAmount(transaction),
+		"transaction_currency": transaction.Currency,
+		"status":               transaction.Status,
+		"payment_type":         transaction.PaymentType,
+		"flwMeta": map[string]interface{}{
+			"chargeResponse":        chargeResponseCode(transaction),
+			"chargeResponseMessage": transaction.Status,
+		},
+		"customer": map[string]interface{}{"email": transaction.Email},
+	})
+}
+
+// handleXrequery : Verify a transaction with xrequery
+func (s *Server) handleXrequery(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "SECKEY")
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction := s.findTransaction(stringValue(body, "flw_ref"), stringValue(body, "txref"))
+	if transaction == nil {
+		fail(w, http.StatusBadRequest, "No transaction found")
+		return
+	}
+
+	success(w, "Tx Fetched", map[string]interface{}{
+		"txid":          transaction.ID,
+		"flwref":        transaction.FlwRef,
+		"txref":         transaction.TxRef,
+		"amount":        transaction.Amount,
+		"chargedamount": chargedAmount(transaction),
+		"currency":      transaction.Currency,
+		"chargecode":    chargeResponseCode(transaction),
+		"chargemessage": transaction.Status,
+		"status":        transaction.Status,
+		"paymenttype":   transaction.PaymentType,
+		"custemail":     transaction.Email,
+	})
+}
+
+// handleCapture : Capture a preauthorized transaction
+func (s *Server) handleCapture(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "SECKEY")
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction := s.findTransaction(stringValue(body, "flwRef"), "")
+	if transaction == nil {
+		fail(w, http.StatusBadRequest, "Transaction not found")
+		return
+	}
+
+	if transaction.Status != StatusPendingCapture {
+		fail(w, http.StatusBadRequest, "Transaction is not pending capture")
+		return
+	}
+
+	// partial captures can't be more than the preauthorized amount
+	if amount := stringValue(body, "amount"); amount != "" {
+		captured, err := strconv.ParseFloat(amount, 64)
+		authorized, _ := transaction.Amount.Float64()
+		if err != nil || captured <= 0 || captured > authorized {
+			fail(w, http.StatusBadRequest, "Invalid capture amount")
+			return
+		}
+
+		transaction.CapturedAmount = number(amount)
+	}
+
+	transaction.Status = StatusSuccessful
+
+	success(w, "Capture complete", s.chargeData(transaction))
+}
+
+// handleRefundOrVoid : Void a preauthorized transaction or refund a captured one
+func (s *Server) handleRefundOrVoid(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "SECKEY")
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction := s.findTransaction(stringValue(body, "ref"), "")
+	if transaction == nil || transaction.ChargeType != "preauth" {
+		fail(w, http.StatusBadRequest, "Preauthorized transaction not found")
+		return
+	}
+
+	// holds release the authorized amount, captured transactions the captured amount
+	released := transaction.Amount
+	if transaction.Status == StatusSuccessful {
+		released = chargedAmount(transaction)
+	}
+
+	switch action := stringValue(body, "action"); {
+	case action == "void" && (transaction.Status == StatusPendingCapture || transaction.Status == StatusSuccessful):
+		transaction.Status = StatusVoided
+	case action == "refund" && transaction.Status == StatusSuccessful:
+		transaction.Status = StatusRefunded
+	default:
+		fail(w, http.StatusBadRequest, fmt.Sprintf("Can't %s a transaction that is %s", action, transaction.Status))
+		return
+	}
+
+	success(w, "Refund or void complete", map[string]interface{}{
+		"data": map[string]interface{}{
+			"responsecode": "00", "responsemessage": transaction.Status, "amount": released,
+			"currency": transaction.Currency,
+		},
+		"status": "success",
+	})
+}
+
+// handleRefund : Refund a direct charge
+func (s *Server) handleRefund(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "seckey")
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction := s.findTransaction(stringValue(body, "ref"), "")
+	if transaction == nil {
+		fail(w, http.StatusBadRequest, "Transaction not found")
+		return
+	}
+
+	if transaction.Status != StatusSuccessful {
+		fail(w, http.StatusBadRequest, "Only successful transactions can be refunded")
+		return
+	}
+
+	transaction.Status = StatusRefunded
+
+	success(w, "Refunded", map[string]interface{}{
+		"AmountRefunded": transaction.Amount,
+		"FlwRef":         transaction.FlwRef,
+		"status":         "completed",
+		"currency":       transaction.Currency,
+	})
+}
+
+// handleTokenCharge : Charge a card again with the embed_token of a previous charge
+func (s *Server) handleTokenCharge(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "SECKEY")
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token := stringValue(body, "token")
+	chargeToken, ok := s.tokens[token]
+	switch {
+	case !ok:
+		fail(w, http.StatusBadRequest, "Invalid token")
+		return
+	case chargeToken.expired:
+		fail(w, http.StatusBadRequest, "Token has expired")
+		return
+	case !strings.EqualFold(chargeToken.email, stringValue(body, "email")):
+		fail(w, http.StatusBadRequest, "Token does not belong to this customer")
+		return
+	}
+
+	transaction := s.newTransaction(body, "card")
+	transaction.AuthModel = "noauth"
+	transaction.Status = StatusSuccessful
+	transaction.EmbedToken = token
+
+	success(w, "Charge success", s.chargeData(transaction))
+}
+
+// handleFee : Fees are 1.4% (capped at 2000) for NGN and 3.8% for other currencies
+func (s *Server) handleFee(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "PBFPubKey")
+	if !ok {
+		return
+	}
+
+	amount, err := strconv.ParseFloat(stringValue(body, "amount"), 64)
+	if err != nil || amount <= 0 {
+		fail(w, http.StatusBadRequest, "Invalid amount")
+		return
+	}
+
+	fee := amount * 0.038
+	if stringValue(body, "currency") == "NGN" {
+		fee = amount * 0.014
+		if fee > 2000 {
+			fee = 2000
+		}
+	}
+
+	success(w, "Charged fee", map[string]interface{}{
+		"charge_amount": fmt.Sprintf("%.2f", amount+fee),
+		"fee":           json.Number(strconv.FormatFloat(fee, 'f', 2, 64)),
+		"merchantfee":   "0",
+		"ravefee":       fmt.Sprintf("%.2f", fee),
+	})
+}
+
+// Banks : Banks returned by the banks endpoint
+var Banks = []map[string]interface{}{
+	{"bankname": "ACCESS BANK NIGERIA", "bankcode": "044", "internetbanking": false},
+	{"bankname": "ECOBANK NIGERIA PLC", "bankcode": "050", "internetbanking": false},
+	{"bankname": "FIRST BANK PLC", "bankcode": "011", "internetbanking": false},
+	{"bankname": "GTBANK PLC", "bankcode": "058", "internetbanking": true},
+	{"bankname": "UNITED BANK FOR AFRICA PLC", "bankcode": "033", "internetbanking": false},
+	{"bankname": "ZENITH BANK PLC", "bankcode": "057", "internetbanking": false},
+}
+
+// handleBanks : List Nigerian banks
+func (s *Server) handleBanks(w http.ResponseWriter, req *http.Request) {
+	respond(w, http.StatusOK, Banks)
+}
+
+// handleAuth : 3DSecure page, completes the transaction and redirects the
+// customer to the redirect_url with the transaction in the "response" parameter
+func (s *Server) handleAuth(w http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transaction := s.findTransaction(strings.TrimPrefix(req.URL.Path, AuthPath), "")
+	if transaction == nil || transaction.Status != StatusPendingValidation {
+		http.NotFound(w, req)
+		return
+	}
+
+	transaction.Status = StatusSuccessful
+	if transaction.ChargeType == "preauth" {
+		transaction.Status = StatusPendingCapture
+	}
+
+	response, _ := json.Marshal(s.chargeData(transaction))
+	redirectURL := transaction.RedirectURL + "?response=" + url.QueryEscape(string(response))
+
+	http.Redirect(w, req, redirectURL, http.StatusFound)
+}
@@ -0,0 +1,322 @@
+// Tests for the fake Rave server, driven by the rave client
+
+package ravetest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	rave "github.com/danidee10/go-rave/rave"
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// newClient : Returns a client that sends every request to server
+func newClient(server *ravetest.Server, opts ...rave.Option) rave.Rave {
+	opts = append([]rave.Option{
+		rave.WithKeys(server.PublicKey, server.SecretKey),
+		rave.WithBaseURLs("", server.URL),
+		rave.WithHTTPClient(server.Client()),
+		rave.WithRetryPolicy(rave.NoRetries),
+	}, opts...)
+
+	return rave.NewClient(opts...)
+}
+
+// customer : Customer used by every charge
+var customer = rave.Customer{
+	Email: "user@example.com", PhoneNumber: "0902620185", FirstName: "Temi", LastName: "Adebayo", IP: "127.0.0.1",
+}
+
+// cardCharge : Returns a charge for cardNo
+func cardCharge(cardNo, txRef string) rave.CardChargeRequest {
+	return rave.CardChargeRequest{
+		Card: rave.Card{
+			CardNo: cardNo, CVV: "564", ExpiryMonth: "10", ExpiryYear: "20", Pin: "3310",
+		},
+		Customer:    customer,
+		Amount:      rave.MustMoney("300", "NGN"),
+		Currency:    "NGN",
+		Country:     "NG",
+		TxRef:       txRef,
+		RedirectURL: "https://example.com/callback",
+	}
+}
+
+func TestPinChargeAndValidation(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newClient(server)
+	ctx := context.Background()
+
+	charge, err := client.ChargeCardTyped(ctx, cardCharge(ravetest.PinCard, "pin-tx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if charge.Data.AuthModelUsed != "PIN" || charge.Data.ChargeResponseCode != "02" {
+		t.Fatalf("Expected a PIN charge pending validation got %+v", charge.Data)
+	}
+
+	// the first charge asks for the PIN, the second one sends it
+	if requests := server.Requests(ravetest.ChargePath); requests != 2 {
+		t.Errorf("Expected 2 charge requests got %d", requests)
+	}
+
+	charges := server.Charges()
+	if len(charges) != 2 || charges[1]["pin"] != "3310" || charges[1]["suggested_auth"] != "PIN" {
+		t.Errorf("Unexpected charge payloads %v", charges)
+	}
+
+	_, err = client.ValidateChargeTyped(ctx, rave.ValidateChargeRequest{
+		TransactionReference: charge.Data.FlwRef, OTP: "00000",
+	})
+	apiError := &rave.APIError{}
+	if !errors.As(err, &apiError) || apiError.Message != "Invalid OTP" {
+		t.Errorf("Expected an invalid OTP error got %v", err)
+	}
+
+	validation, err := client.ValidateChargeTyped(ctx, rave.ValidateChargeRequest{
+		TransactionReference: charge.Data.FlwRef, OTP: ravetest.ValidOTP,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if validation.Data.Tx.ChargeResponseCode != "00" {
+		t.Errorf("Expected a successful validation got %+v", validation.Data)
+	}
+
+	for _, verify := range []func(context.Context, rave.VerifyRequest) (*rave.VerifyResponse, error){
+		client.VerifyTransactionTyped, client.XrequeryTransactionVerificationTyped,
+	} {
+		response, err := verify(ctx, rave.VerifyRequest{
+			FlwRef: charge.Data.FlwRef, TxRef: "pin-tx", Amount: rave.MustMoney("300", "NGN"), Currency: "NGN",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, response.Data.Status, ravetest.StatusSuccessful)
+		assertEqual(t, response.Data.ChargeCode, "00")
+		assertEqual(t, response.Data.Amount, rave.MustMoney("300", "NGN"))
+		assertEqual(t, response.Data.Currency, "NGN")
+	}
+}
+
+func Test3DSecureCharge(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	charge, err := newClient(server).ChargeCardTyped(context.Background(), cardCharge(ravetest.ThreeDSecureCard, "3ds-tx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if charge.Data.AuthURL != server.AuthURL(charge.Data.FlwRef) {
+		t.Fatalf("Expected the auth url of the server got '%s'", charge.Data.AuthURL)
+	}
+
+	// visit the auth page without following the redirect
+	httpClient := server.Client()
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	response, err := httpClient.Get(charge.Data.AuthURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, response.StatusCode, http.StatusFound)
+	assertEqual(t, location.Host, "example.com")
+	if !strings.Contains(location.Query().Get("response"), charge.Data.FlwRef) {
+		t.Errorf("Expected the transaction in the redirect got '%s'", location)
+	}
+
+	transaction, _ := server.Transaction(charge.Data.FlwRef)
+	assertEqual(t, transaction.Status, ravetest.StatusSuccessful)
+}
+
+func TestDeclinedCharge(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	_, err := newClient(server).ChargeCardTyped(context.Background(), cardCharge(ravetest.DeclinedCard, "declined-tx"))
+	if !rave.IsDeclined(err) {
+		t.Errorf("Expected a declined charge got %v", err)
+	}
+}
+
+func TestPreauthCaptureAndRefund(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newClient(server)
+	ctx := context.Background()
+
+	charge, err := client.PreauthorizeCardTyped(ctx, cardCharge(ravetest.NoAuthCard, "preauth-tx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, charge.Data.Status, ravetest.StatusPendingCapture)
+
+	captured, err := client.CaptureTyped(ctx, rave.CaptureRequest{FlwRef: charge.Data.FlwRef})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, captured.Data.Status, ravetest.StatusSuccessful)
+
+	// a transaction can only be captured once
+	if _, err := client.CaptureTyped(ctx, rave.CaptureRequest{FlwRef: charge.Data.FlwRef}); err == nil {
+		t.Error("Expected an error when capturing twice")
+	}
+
+	_, err = client.RefundOrVoidPreauthTyped(ctx, rave.RefundOrVoidRequest{Ref: charge.Data.FlwRef, Action: "refund"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transaction, _ := server.Transaction(charge.Data.FlwRef)
+	assertEqual(t, transaction.Status, ravetest.StatusRefunded)
+}
+
+func TestAccountChargeAndRefund(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newClient(server)
+	ctx := context.Background()
+
+	charge, err := client.ChargeAccountTyped(ctx, rave.AccountChargeRequest{
+		Customer:      customer,
+		AccountNumber: "0690000031",
+		AccountBank:   "044",
+		Amount:        rave.MustMoney("500", "NGN"),
+		Currency:      "NGN",
+		Country:       "NG",
+		TxRef:         "account-tx",
+		PaymentType:   "account",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, charge.Data.Status, ravetest.StatusPendingValidation)
+
+	_, err = client.ValidateAccountChargeTyped(ctx, rave.ValidateAccountChargeRequest{
+		TransactionReference: charge.Data.FlwRef, OTP: ravetest.ValidOTP,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refund, err := client.RefundTransactionTyped(ctx, rave.RefundRequest{Ref: charge.Data.FlwRef})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, refund.Data.AmountRefunded, rave.MustMoney("500", "NGN"))
+}
+
+func TestFeesAndBanks(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newClient(server)
+
+	fees, err := client.GetFeesTyped(context.Background(), rave.FeeRequest{Amount: rave.MustMoney("1000", "NGN"), Currency: "NGN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, fees.Data.Fee, rave.MustMoney("14", "NGN"))
+
+	banks, err := client.ListBanksTyped(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, banks[0], rave.Bank{Name: "ACCESS BANK NIGERIA", Code: "044"})
+}
+
+func TestWrongKeys(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newClient(server, rave.WithKeys("FLWPUBK-wrong-X", "FLWSECK-wrong-secret-key-X"))
+
+	_, err := client.VerifyTransactionTyped(context.Background(), rave.VerifyRequest{FlwRef: "FLW-MOCK-000001", Amount: rave.MustMoney("300", "NGN"), Currency: "NGN"})
+	if !rave.IsAuthError(err) {
+		t.Errorf("Expected an auth error got %v", err)
+	}
+
+	// charges encrypted with another secret key can't be read by the server
+	_, err = client.ChargeCardTyped(context.Background(), cardCharge(ravetest.PinCard, "wrong-keys"))
+	if !rave.IsAuthError(err) {
+		t.Errorf("Expected an auth error got %v", err)
+	}
+}
+
+func TestScriptedResponses(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	server.Script(ravetest.FeePath, ravetest.ServerError(), ravetest.MalformedBody())
+	server.Script(ravetest.ChargePath, ravetest.Decline("Insufficient funds"))
+	server.Script(ravetest.VerifyPath, ravetest.Timeout(time.Second))
+
+	client := newClient(server, rave.WithRetryPolicy(rave.RetryPolicy{MaxAttempts: 3}))
+	ctx := context.Background()
+
+	// both scripted failures are retried before the real response is returned
+	fees, err := client.GetFeesTyped(ctx, rave.FeeRequest{Amount: rave.MustMoney("100", "USD")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, fees.Data.Fee, rave.MustMoney("3.80", "USD"))
+	assertEqual(t, server.Requests(ravetest.FeePath), 3)
+
+	_, err = client.ChargeCardTyped(ctx, cardCharge(ravetest.NoAuthCard, "scripted-decline"))
+	if !rave.IsDeclined(err) || !strings.Contains(err.Error(), "Insufficient funds") {
+		t.Errorf("Expected a declined charge got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.VerifyTransactionTyped(ctx, rave.VerifyRequest{FlwRef: "FLW-MOCK-000001", Amount: rave.MustMoney("300", "NGN"), Currency: "NGN"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded got %v", err)
+	}
+}
+
+// assertEqual : Fail the test if actual != expected
+func assertEqual(t *testing.T, actual, expected interface{}) {
+	t.Helper()
+
+	if actual != expected {
+		t.Errorf("Expected '%v' got '%v'", expected, actual)
+	}
+}
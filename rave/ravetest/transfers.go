@@ -0,0 +1,360 @@
+// Implements the transfer (payout) and balance endpoints
+
+package ravetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Paths of the transfer endpoints
+const (
+	TransferPath     = "/v2/gpx/transfers/create"
+	BulkTransferPath = "/v2/gpx/transfers/create_bulk"
+	TransfersPath    = "/v2/gpx/transfers"
+	TransferFeePath  = "/v2/gpx/transfers/fee"
+	BalancePath      = "/v2/gpx/balance"
+)
+
+// Payout statuses, payouts stay new until CompletePayout is called
+const (
+	PayoutNew        = "NEW"
+	PayoutSuccessful = "SUCCESSFUL"
+	PayoutFailed     = "FAILED"
+)
+
+// DefaultBalance : Balance of every currency unless SetBalance is called
+const DefaultBalance = 1000000
+
+// TransferFees : Flat fee charged for transfers in each currency
+var TransferFees = map[string]float64{"NGN": 45, "GHS": 1, "KES": 50, "USD": 10}
+
+// payoutsPerPage : Number of payouts returned by each page of the list endpoint
+const payoutsPerPage = 10
+
+// Payout : A transfer (payout) created by the server
+type Payout struct {
+	ID            int
+	BulkID        int
+	Reference     string
+	AccountBank   string
+	AccountNumber string
+	Amount        float64
+	Fee           float64
+	Currency      string
+	Narration     string
+	Status        string
+	Created       time.Time
+}
+
+// SetBalance : Set the balance transfers in currency are paid from
+func (s *Server) SetBalance(currency string, balance float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.balances[strings.ToUpper(currency)] = balance
+}
+
+// Payout : Get a copy of the payout with reference
+func (s *Server) Payout(reference string) (Payout, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	payout := s.findPayout(reference)
+	if payout == nil {
+		return Payout{}, false
+	}
+
+	return *payout, true
+}
+
+// CompletePayout : Mark a new payout as successful or failed (the amount and fee
+// are returned to the balance). It reports whether the payout was new.
+func (s *Server) CompletePayout(reference string, successful bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	payout := s.findPayout(reference)
+	if payout == nil || payout.Status != PayoutNew {
+		return false
+	}
+
+	payout.Status = PayoutSuccessful
+	if !successful {
+		payout.Status = PayoutFailed
+		s.balances[payout.Currency] = s.balance(payout.Currency) + payout.Amount + payout.Fee
+	}
+
+	return true
+}
+
+// findPayout : Find a payout by reference, the caller must hold the lock
+func (s *Server) findPayout(reference string) *Payout {
+	for _, payout := range s.payouts {
+		if payout.Reference == reference {
+			return payout
+		}
+	}
+
+	return nil
+}
+
+// balance : Balance of currency, the caller must hold the lock
+func (s *Server) balance(currency string) float64 {
+	if balance, ok := s.balances[currency]; ok {
+		return balance
+	}
+
+	return DefaultBalance
+}
+
+// newPayout : Check a transfer and debit the balance, the caller must hold the lock
+func (s *Server) newPayout(reference, bank, accountNumber, amount, currency, narration string) (*Payout, error) {
+	if s.findPayout(reference) != nil {
+		return nil, fmt.Errorf("A transfer with reference %s already exists", reference)
+	}
+
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil || value <= 0 {
+		return nil, fmt.Errorf("Invalid amount %s", amount)
+	}
+
+	currency = strings.ToUpper(currency)
+	fee, ok := TransferFees[currency]
+	if !ok {
+		return nil, fmt.Errorf("Transfers in %s are not supported", currency)
+	}
+
+	if s.balance(currency) < value+fee {
+		return nil, fmt.Errorf("Insufficient balance in %s wallet", currency)
+	}
+
+	payout := &Payout{
+		ID: s.nextID, Reference: reference, AccountBank: bank, AccountNumber: accountNumber, Amount: value, Fee: fee,
+		Currency: currency, Narration: narration, Status: PayoutNew, Created: time.Now(),
+	}
+	s.nextID++
+
+	return payout, nil
+}
+
+// payoutData : Payout in the format of the transfer endpoints
+func payoutData(payout *Payout) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                payout.ID,
+		"account_number":    payout.AccountNumber,
+		"bank_code":         payout.AccountBank,
+		"bank_name":         "RAVETEST BANK",
+		"fullname":          "Ravetest Beneficiary",
+		"date_created":      payout.Created.UTC().Format(time.RFC3339),
+		"currency":          payout.Currency,
+		"amount":            json.Number(strconv.FormatFloat(payout.Amount, 'f', 2, 64)),
+		"fee":               json.Number(strconv.FormatFloat(payout.Fee, 'f', 2, 64)),
+		"status":            payout.Status,
+		"reference":         payout.Reference,
+		"narration":         payout.Narration,
+		"complete_message":  "",
+		"requires_approval": 0,
+		"is_approved":       1,
+	}
+}
+
+// handleTransfer : Create a transfer to an account or a beneficiary ("recipient")
+func (s *Server) handleTransfer(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "seckey")
+	if !ok {
+		return
+	}
+
+	if !checkFields(w, body, "amount", "currency", "reference") {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// transfers to a beneficiary ("recipient") are paid to it's account
+	bank, accountNumber := stringValue(body, "account_bank"), stringValue(body, "account_number")
+	if recipient := stringValue(body, "recipient"); recipient != "" {
+		_, beneficiary := s.findBeneficiary(recipient)
+		if beneficiary == nil {
+			fail(w, http.StatusBadRequest, fmt.Sprintf("Beneficiary %s not found", recipient))
+			return
+		}
+
+		bank, accountNumber = beneficiary.BankCode, beneficiary.AccountNumber
+	} else if !checkFields(w, body, "account_bank", "account_number") {
+		return
+	}
+
+	payout, err := s.newPayout(
+		stringValue(body, "reference"), bank, accountNumber,
+		stringValue(body, "amount"), stringValue(body, "currency"), stringValue(body, "narration"),
+	)
+	if err != nil {
+		fail(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.balances[payout.Currency] = s.balance(payout.Currency) - payout.Amount - payout.Fee
+	s.payouts = append(s.payouts, payout)
+
+	success(w, "TRANSFER-CREATED", payoutData(payout))
+}
+
+// handleBulkTransfer : Create every transfer of a bulk transfer, none are created if one is invalid
+func (s *Server) handleBulkTransfer(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "seckey")
+	if !ok {
+		return
+	}
+
+	transfers, _ := body["bulk_data"].([]interface{})
+	if len(transfers) == 0 {
+		fail(w, http.StatusBadRequest, "bulk_data is required")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	bulkID := s.nextID
+	s.nextID++
+
+	balances := map[string]float64{}
+	var payouts []*Payout
+	for _, transfer := range transfers {
+		item, _ := transfer.(map[string]interface{})
+		if !checkFields(w, item, "Bank", "Account Number", "Amount", "Currency", "reference") {
+			return
+		}
+
+		payout, err := s.newPayout(
+			stringValue(item, "reference"), stringValue(item, "Bank"), stringValue(item, "Account Number"),
+			stringValue(item, "Amount"), stringValue(item, "Currency"), stringValue(item, "Narration"),
+		)
+		if err == nil {
+			if _, ok := balances[payout.Currency]; !ok {
+				balances[payout.Currency] = s.balance(payout.Currency)
+			}
+
+			balances[payout.Currency] -= payout.Amount + payout.Fee
+			if balances[payout.Currency] < 0 {
+				err = fmt.Errorf("Insufficient balance in %s wallet", payout.Currency)
+			}
+		}
+
+		for _, other := range payouts {
+			if err == nil && other.Reference == payout.Reference {
+				err = fmt.Errorf("A transfer with reference %s already exists", payout.Reference)
+			}
+		}
+
+		if err != nil {
+			fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		payout.BulkID = bulkID
+		payouts = append(payouts, payout)
+	}
+
+	for currency, balance := range balances {
+		s.balances[currency] = balance
+	}
+	s.payouts = append(s.payouts, payouts...)
+
+	success(w, "BULK-TRANSFER-CREATED", map[string]interface{}{
+		"id": bulkID, "date_created": time.Now().UTC().Format(time.RFC3339), "approval_state": "APPROVED",
+	})
+}
+
+// handleTransfers : List transfers, filtered by "reference" or "status" a page at a time
+func (s *Server) handleTransfers(w http.ResponseWriter, req *http.Request) {
+	query, ok := s.decodeQuery(w, req)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	reference, status := query.Get("reference"), strings.ToUpper(query.Get("status"))
+	if status == "PENDING" {
+		status = PayoutNew
+	}
+
+	var transfers []interface{}
+	for _, payout := range s.payouts {
+		if (reference == "" || payout.Reference == reference) && (status == "" || payout.Status == status) {
+			transfers = append(transfers, payoutData(payout))
+		}
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	totalPages := (len(transfers) + payoutsPerPage - 1) / payoutsPerPage
+	start, end := (page-1)*payoutsPerPage, page*payoutsPerPage
+	if start > len(transfers) {
+		start = len(transfers)
+	}
+	if end > len(transfers) {
+		end = len(transfers)
+	}
+
+	success(w, "QUERIED-TRANSFERS", map[string]interface{}{
+		"page_info": map[string]interface{}{"total": len(transfers), "current_page": page, "total_pages": totalPages},
+		"transfers": append([]interface{}{}, transfers[start:end]...),
+	})
+}
+
+// handleTransferFee : Transfer fees of every currency or the one in "currency"
+func (s *Server) handleTransferFee(w http.ResponseWriter, req *http.Request) {
+	query, ok := s.decodeQuery(w, req)
+	if !ok {
+		return
+	}
+
+	currency := strings.ToUpper(query.Get("currency"))
+
+	fees := []interface{}{}
+	for feeCurrency, fee := range TransferFees {
+		if currency == "" || currency == feeCurrency {
+			fees = append(fees, map[string]interface{}{
+				"fee_type": "value", "currency": feeCurrency,
+				"fee": json.Number(strconv.FormatFloat(fee, 'f', 2, 64)), "entity": "all_banks",
+			})
+		}
+	}
+
+	success(w, "TRANSFER-FEE", fees)
+}
+
+// handleBalance : Balance of the wallet in "currency"
+func (s *Server) handleBalance(w http.ResponseWriter, req *http.Request) {
+	body, ok := s.decodeRequest(w, req, "seckey")
+	if !ok {
+		return
+	}
+
+	if !checkFields(w, body, "currency") {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	currency := strings.ToUpper(stringValue(body, "currency"))
+	balance := json.Number(strconv.FormatFloat(s.balance(currency), 'f', 2, 64))
+
+	success(w, "WALLET-BALANCE", map[string]interface{}{
+		"Id": 1, "ShortName": currency, "WalletNumber": "3018000000", "AvailableBalance": balance, "LedgerBalance": balance,
+	})
+}
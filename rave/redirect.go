@@ -0,0 +1,72 @@
+/* This file contains the helpers for charges the customer authorizes on their bank's page (3DSecure and internet banking) */
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrNoRedirectResponse : Returned when the redirect doesn't contain Rave's response
+var ErrNoRedirectResponse = errors.New("The redirect doesn't contain a response from Rave")
+
+// ErrRedirectMismatch : Returned when a redirect is for another transaction than the one the customer was sent to pay
+var ErrRedirectMismatch = errors.New("The redirect is for another transaction")
+
+// ParseRedirect : Decode the transaction Rave sends to the redirect_url ("?response=...")
+// after the customer authorizes a charge.
+// The transaction can't be trusted until it's verified, use VerifyRedirect.
+func ParseRedirect(query url.Values) (*ChargeData, error) {
+	response := query.Get("response")
+	if response == "" {
+		return nil, ErrNoRedirectResponse
+	}
+
+	data := &ChargeData{}
+	err := decodeResponse([]byte(response), data)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.FlwRef == "" {
+		return nil, ErrNoRedirectResponse
+	}
+
+	return data, nil
+}
+
+// VerifyRedirect : Parse the redirect with ParseRedirect and verify the transaction it refers to.
+// txRef and amount are the reference and amount of the charge the customer was sent to pay,
+// they must not be taken from the redirect. ErrRedirectMismatch is returned if the redirect
+// or the verified transaction has another txRef, e.g when the redirect of another payment is replayed.
+func (r Rave) VerifyRedirect(ctx context.Context, query url.Values, txRef string, amount Money) (*VerifyResponse, error) {
+	if txRef == "" {
+		return nil, &ParameterError{Parameter: "txRef", Method: "VerifyRedirect"}
+	}
+
+	data, err := ParseRedirect(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.TxRef != txRef {
+		return nil, fmt.Errorf("%w: the redirect is for \"%s\" not \"%s\"", ErrRedirectMismatch, data.TxRef, txRef)
+	}
+
+	verifyResponse, err := r.VerifyTransactionTyped(ctx, VerifyRequest{
+		FlwRef: data.FlwRef, Amount: amount, Currency: amount.Currency, Normalize: "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if verifyResponse.Data.TxRef != txRef {
+		return nil, fmt.Errorf(
+			"%w: the transaction is \"%s\" not \"%s\"", ErrRedirectMismatch, verifyResponse.Data.TxRef, txRef,
+		)
+	}
+
+	return verifyResponse, nil
+}
@@ -0,0 +1,171 @@
+// Tests for charges authorized on the bank's page
+
+package rave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// Account charges should report whether they're validated with an OTP or at the auth URL
+func TestAccountChargeAuthFlow(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	request := AccountChargeRequest{
+		Customer: customer, AccountNumber: "0690000031", AccountBank: "044", Amount: MustMoney("1000", "NGN"),
+		TxRef: "account-otp", PaymentType: "account", RedirectURL: "https://example.com/callback",
+	}
+	otpCharge, err := client.ChargeAccountTyped(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, otpCharge.Data.AuthFlow(), AuthFlowOTP)
+
+	request.AccountNumber, request.TxRef = ravetest.InternetBankingAccount, "account-redirect"
+	redirectCharge, err := client.ChargeAccountTyped(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, redirectCharge.Data.AuthFlow(), AuthFlowRedirect)
+	assertEqual(t, redirectCharge.Data.AuthURL, server.AuthURL(redirectCharge.Data.FlwRef))
+
+	// internet banking charges can't be validated with an OTP
+	_, err = client.ValidateAccountChargeTyped(ctx, ValidateAccountChargeRequest{
+		TransactionReference: redirectCharge.Data.FlwRef, OTP: ravetest.ValidOTP,
+	})
+	if err == nil {
+		t.Fatal("Expected the OTP to be rejected")
+	}
+
+	validated, err := client.ValidateAccountChargeTyped(ctx, ValidateAccountChargeRequest{
+		TransactionReference: otpCharge.Data.FlwRef, OTP: ravetest.ValidOTP,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, validated.Data.AuthFlow(), AuthFlowNone)
+}
+
+// redirectQuery : Authorize a charge at authURL and return the query of the redirect back to the merchant
+func redirectQuery(t *testing.T, server *ravetest.Server, authURL string) url.Values {
+	t.Helper()
+
+	httpClient := *server.Client()
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	response, err := httpClient.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	location, err := response.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return location.Query()
+}
+
+// The redirect back from the bank should be verified with the amount the customer had to pay
+func TestVerifyRedirect(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	charge, err := client.ChargeAccountTyped(ctx, AccountChargeRequest{
+		Customer: customer, AccountNumber: ravetest.InternetBankingAccount, AccountBank: "044", Amount: MustMoney("1000", "NGN"),
+		TxRef: "account-redirect", PaymentType: "account", RedirectURL: "https://example.com/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := redirectQuery(t, server, charge.Data.AuthURL)
+
+	redirect, err := ParseRedirect(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, redirect.FlwRef, charge.Data.FlwRef)
+	assertEqual(t, redirect.Amount, MustMoney("1000", "NGN"))
+
+	verifyResponse, err := client.VerifyRedirect(ctx, query, "account-redirect", MustMoney("1000", "NGN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, verifyResponse.Data.FlwRef, charge.Data.FlwRef)
+
+	// a redirect for a smaller amount than the customer had to pay isn't verified
+	_, err = client.VerifyRedirect(ctx, query, "account-redirect", MustMoney("5000", "NGN"))
+	verificationError := &VerificationError{}
+	if !errors.As(err, &verificationError) || !verificationError.Failed(RuleChargedAmount) {
+		t.Fatalf("Expected the charged amount rule to fail got %v", err)
+	}
+
+	_, err = client.VerifyRedirect(ctx, url.Values{}, "account-redirect", MustMoney("1000", "NGN"))
+	assertEqual(t, err, ErrNoRedirectResponse)
+
+	_, err = client.VerifyRedirect(ctx, query, "", MustMoney("1000", "NGN"))
+	assertEqual(t, err.Error(), "\"txRef\" is a required parameter for \"VerifyRedirect\"")
+}
+
+// The redirect of another successful payment of the same amount shouldn't verify a charge
+func TestVerifyRedirectReplayed(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	request := AccountChargeRequest{
+		Customer: customer, AccountNumber: ravetest.InternetBankingAccount, AccountBank: "044", Amount: MustMoney("1000", "NGN"),
+		TxRef: "account-paid", PaymentType: "account", RedirectURL: "https://example.com/callback",
+	}
+	paid, err := client.ChargeAccountTyped(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paidQuery := redirectQuery(t, server, paid.Data.AuthURL)
+
+	request.TxRef = "account-unpaid"
+	if _, err := client.ChargeAccountTyped(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.VerifyRedirect(ctx, paidQuery, "account-unpaid", MustMoney("1000", "NGN"))
+	if !errors.Is(err, ErrRedirectMismatch) {
+		t.Errorf("Expected ErrRedirectMismatch got %v", err)
+	}
+
+	// the txRef in the redirect can be edited, the verified transaction must match as well
+	redirect, err := ParseRedirect(paidQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := json.Marshal(map[string]interface{}{"flwRef": redirect.FlwRef, "txRef": "account-unpaid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.VerifyRedirect(ctx, url.Values{"response": {string(forged)}}, "account-unpaid", MustMoney("1000", "NGN"))
+	if !errors.Is(err, ErrRedirectMismatch) {
+		t.Errorf("Expected ErrRedirectMismatch got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+/* This file contains the retry policy used for failed requests */
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrChargeAlreadySubmitted : Returned instead of retrying a charge when Rave already has a
+// transaction with the same txRef. Verify the transaction instead of charging again.
+var ErrChargeAlreadySubmitted = errors.New("A transaction with this txRef already exists, verify it instead of charging again")
+
+// ErrTransferAlreadySubmitted : Returned instead of retrying a transfer when Rave already has a
+// transfer with the same reference. Get it with GetTransfer instead of sending it again.
+var ErrTransferAlreadySubmitted = errors.New("A transfer with this reference already exists, get it instead of sending it again")
+
+// RetryPolicy : Controls how failed requests are retried.
+//
+// Idempotent calls (VerifyTransaction, XrequeryTransactionVerification, GetFees
+// and ListBanks) are retried by default. Charges are only retried when
+// RetryCharges is set and the charge has a "txRef", before every retry Rave is
+// asked whether a transaction with that txRef already exists so the customer
+// is never charged twice. Transfers are retried the same way (using their
+// "reference") when RetryTransfers is set.
+type RetryPolicy struct {
+	// Total number of attempts including the first one, 1 or less disables retries
+	MaxAttempts int
+
+	// Delay before the first retry, it's doubled after every attempt up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Randomize every delay between half and all of it's value
+	Jitter bool
+
+	// Reports whether a failed request should be retried, defaults to IsRetryable
+	// (network errors, 408, 429 and 5xx responses)
+	ShouldRetry func(err error) bool
+
+	// Retry charges that have a "txRef"
+	RetryCharges bool
+
+	// Retry transfers, they always have a "reference"
+	RetryTransfers bool
+}
+
+// DefaultRetryPolicy : Used by clients created without WithRetryPolicy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Jitter:      true,
+}
+
+// NoRetries : Retry policy that sends every request only once
+var NoRetries = RetryPolicy{MaxAttempts: 1}
+
+// WithRetryPolicy : Use a custom retry policy for failed requests
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(r *Rave) {
+		r.retryPolicy = policy
+	}
+}
+
+// shouldRetry : Reports whether the request that failed with err should be retried
+func (p RetryPolicy) shouldRetry(err error) bool {
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(err)
+	}
+
+	return IsRetryable(err)
+}
+
+// delay : Returns how long to wait before the given retry (starting from 1)
+func (p RetryPolicy) delay(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter && delay > 0 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+
+	return delay
+}
+
+// withRetries : Send a request and retry it according to the client's retry policy.
+// beforeRetry (if it's not nil) is called with the last error before every
+// retry, the request isn't retried and it's error is returned if it's not nil.
+func (r Rave) withRetries(
+	ctx context.Context, beforeRetry func(lastErr error) error, request func() ([]byte, error),
+) ([]byte, error) {
+	policy := r.retryPolicy
+
+	response, err := request()
+	for attempt := 1; err != nil && attempt < policy.MaxAttempts && policy.shouldRetry(err); attempt++ {
+		timer := time.NewTimer(policy.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if beforeRetry != nil {
+			if retryErr := beforeRetry(err); retryErr != nil {
+				return nil, retryErr
+			}
+		}
+
+		response, err = request()
+	}
+
+	return response, err
+}
+
+// withChargeRetries : Send a charge and retry it only if the policy allows it and it has a txRef
+func (r Rave) withChargeRetries(
+	ctx context.Context, txRef interface{}, request func() ([]byte, error),
+) ([]byte, error) {
+	reference, ok := txRef.(string)
+	if !ok || reference == "" || !r.retryPolicy.RetryCharges {
+		return request()
+	}
+
+	// Don't retry if the last attempt reached Rave, or we can't tell whether it did
+	beforeRetry := func(lastErr error) error {
+		exists, err := r.transactionExists(ctx, reference)
+		if err != nil {
+			return lastErr
+		}
+
+		if exists {
+			return ErrChargeAlreadySubmitted
+		}
+
+		return nil
+	}
+
+	return r.withRetries(ctx, beforeRetry, request)
+}
+
+// withTransferRetries : Send a transfer and retry it only if the policy allows it
+func (r Rave) withTransferRetries(
+	ctx context.Context, reference interface{}, request func() ([]byte, error),
+) ([]byte, error) {
+	transferReference, ok := reference.(string)
+	if !ok || transferReference == "" || !r.retryPolicy.RetryTransfers {
+		return request()
+	}
+
+	// Don't retry if the last attempt reached Rave, or we can't tell whether it did
+	beforeRetry := func(lastErr error) error {
+		_, err := r.GetTransferTyped(ctx, transferReference)
+		switch {
+		case err == nil:
+			return ErrTransferAlreadySubmitted
+		case errors.Is(err, ErrTransferNotFound):
+			return nil
+		}
+
+		return lastErr
+	}
+
+	return r.withRetries(ctx, beforeRetry, request)
+}
+
+// transactionExists : Ask Rave (using xrequery) whether a transaction with txRef exists
+func (r Rave) transactionExists(ctx context.Context, txRef string) (bool, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return false, err
+	}
+
+	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/xrequery"
+	data := map[string]interface{}{"txref": txRef, "SECKEY": secretKey, "last_attempt": "1"}
+
+	_, err = r.makePostRequest(ctx, URL, data)
+	if err != nil {
+		// Rave returns an error when no transaction matches the reference, any other error is inconclusive
+		apiError := &APIError{}
+		if errors.As(err, &apiError) && apiError.transactionNotFound() {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
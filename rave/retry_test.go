@@ -0,0 +1,251 @@
+// Tests for the retry policy
+
+package rave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fastRetries : Retry policy with short delays for tests
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// flakyServer : Fake Rave server that fails the first `failures` requests to each
+// endpoint with a 503 and counts the requests it receives
+type flakyServer struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	failures int
+	requests map[string]int
+
+	// transactions known to the xrequery endpoint
+	transactions map[string]bool
+
+	// message of the error returned by lookups instead of "No transaction found"
+	lookupError string
+}
+
+func newFlakyServer(failures int) *flakyServer {
+	server := &flakyServer{failures: failures, requests: map[string]int{}, transactions: map[string]bool{}}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+
+	return server
+}
+
+func (s *flakyServer) handle(w http.ResponseWriter, req *http.Request) {
+	body := map[string]interface{}{}
+	json.NewDecoder(req.Body).Decode(&body)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := req.URL.Path
+	s.requests[path]++
+
+	// lookups made before retrying a charge
+	if _, ok := body["txref"]; ok {
+		if s.transactions[body["txref"].(string)] {
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": map[string]interface{}{}})
+			return
+		}
+
+		message := "No transaction found"
+		if s.lookupError != "" {
+			message = s.lookupError
+		}
+
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "error", "message": message})
+		return
+	}
+
+	if s.requests[path] <= s.failures {
+		w.WriteHeader(503)
+		w.Write([]byte("Service Unavailable"))
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success", "message": "Tx Fetched",
+		"data": map[string]interface{}{
+			"flwref": "FLW-MOCK", "chargedamount": 300, "currency": "NGN", "chargecode": "00",
+		},
+	})
+}
+
+func (s *flakyServer) requestCount(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.requests[path]
+}
+
+// cardCharge : Valid card charge used by the retry tests
+func cardCharge() map[string]interface{} {
+	return map[string]interface{}{
+		"cardno": "5438898014560229", "cvv": "789", "expirymonth": "09", "expiryyear": "19",
+		"amount": "300", "email": "user@example.com", "phonenumber": "081245554343",
+		"firstname": "user", "lastname": "example", "IP": "103.238.105.185",
+		"txRef": "MXX-AYT-4578", "redirect_url": "http://127.0.0.1",
+	}
+}
+
+func newRetryClient(server *flakyServer, policy RetryPolicy) Rave {
+	return NewClient(
+		WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"),
+		WithBaseURLs("", server.URL),
+		WithRetryPolicy(policy),
+	)
+}
+
+// Idempotent calls should be retried until they succeed
+func TestRetryIdempotentCalls(t *testing.T) {
+	t.Parallel()
+
+	server := newFlakyServer(2)
+	defer server.Close()
+
+	client := newRetryClient(server, fastRetries)
+
+	_, err := client.XrequeryTransactionVerification(map[string]interface{}{
+		"flw_ref": "FLW-MOCK", "amount": "300", "currency": "NGN",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, server.requestCount("/flwv3-pug/getpaidx/api/xrequery"), 3)
+}
+
+// Requests should stop being retried after MaxAttempts
+func TestRetryMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	server := newFlakyServer(5)
+	defer server.Close()
+
+	client := newRetryClient(server, fastRetries)
+
+	_, err := client.GetFees(map[string]interface{}{"amount": "300", "currency": "NGN"})
+
+	assertEqual(t, IsRetryable(err), true)
+	assertEqual(t, server.requestCount("/flwv3-pug/getpaidx/api/fee"), 3)
+}
+
+// Charges should not be retried unless RetryCharges is set
+func TestChargesAreNotRetriedByDefault(t *testing.T) {
+	t.Parallel()
+
+	server := newFlakyServer(1)
+	defer server.Close()
+
+	client := newRetryClient(server, fastRetries)
+
+	_, err := client.ChargeCard(cardCharge())
+
+	assertEqual(t, IsRetryable(err), true)
+	assertEqual(t, server.requestCount("/flwv3-pug/getpaidx/api/charge"), 1)
+}
+
+// Charges with a txRef should be retried when RetryCharges is set
+func TestRetryChargesWithTxRef(t *testing.T) {
+	t.Parallel()
+
+	server := newFlakyServer(1)
+	defer server.Close()
+
+	policy := fastRetries
+	policy.RetryCharges = true
+	client := newRetryClient(server, policy)
+
+	_, err := client.ChargeCard(cardCharge())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, server.requestCount("/flwv3-pug/getpaidx/api/charge"), 2)
+}
+
+// Charges should not be retried if Rave already has a transaction with the same txRef
+func TestRetryChargesDoesNotChargeTwice(t *testing.T) {
+	t.Parallel()
+
+	server := newFlakyServer(1)
+	server.transactions["MXX-AYT-4578"] = true
+	defer server.Close()
+
+	policy := fastRetries
+	policy.RetryCharges = true
+	client := newRetryClient(server, policy)
+
+	_, err := client.ChargeCard(cardCharge())
+
+	assertEqual(t, err, ErrChargeAlreadySubmitted)
+	assertEqual(t, server.requestCount("/flwv3-pug/getpaidx/api/charge"), 1)
+}
+
+// Charges should not be retried if the lookup doesn't say whether the transaction exists
+func TestRetryChargesInconclusiveLookup(t *testing.T) {
+	t.Parallel()
+
+	server := newFlakyServer(1)
+	server.lookupError = "Invalid request"
+	defer server.Close()
+
+	policy := fastRetries
+	policy.RetryCharges = true
+	client := newRetryClient(server, policy)
+
+	_, err := client.ChargeCard(cardCharge())
+
+	apiError := &APIError{}
+	if !errors.As(err, &apiError) || apiError.HTTPStatusCode != 503 {
+		t.Fatalf("Expected the 503 of the charge got %v", err)
+	}
+	assertEqual(t, server.requestCount("/flwv3-pug/getpaidx/api/charge"), 1)
+}
+
+// Retries should stop when the context is canceled during the backoff
+func TestRetryHonorsContext(t *testing.T) {
+	t.Parallel()
+
+	server := newFlakyServer(5)
+	defer server.Close()
+
+	client := newRetryClient(server, RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.ListBanksContext(ctx)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded got %v", err)
+	}
+	assertEqual(t, server.requestCount("/flwv3-pug/getpaidx/api/flwpbf-banks.js"), 1)
+}
+
+// Delays should double after every retry without going over MaxDelay
+func TestRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	assertEqual(t, policy.delay(1), 100*time.Millisecond)
+	assertEqual(t, policy.delay(2), 200*time.Millisecond)
+	assertEqual(t, policy.delay(3), 300*time.Millisecond)
+
+	policy.Jitter = true
+	for retry := 1; retry < 10; retry++ {
+		if delay := policy.delay(retry); delay < 50*time.Millisecond || delay > 300*time.Millisecond {
+			t.Errorf("Delay %s is out of range", delay)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Generates vectors.json with the encryption snippet from Rave's documentation
+// (getKey + 3DES-ECB with PKCS7 padding) so the Go implementation can be
+// checked against the one used in browsers.
+//
+//   node testdata/encryption/generate.js > testdata/encryption/vectors.json
+
+const crypto = require('crypto');
+
+function getKey(seckey) {
+  const keymd5 = crypto.createHash('md5').update(seckey).digest('hex');
+  const keymd5last12 = keymd5.substr(-12);
+  const seckeyadjusted = seckey.replace('FLWSECK-', '');
+  const seckeyadjustedfirst12 = seckeyadjusted.substr(0, 12);
+
+  return seckeyadjustedfirst12 + keymd5last12;
+}
+
+function encrypt(key, text) {
+  const cipher = crypto.createCipheriv('des-ede3', Buffer.from(key, 'utf-8'), null);
+
+  return Buffer.concat([cipher.update(text, 'utf-8'), cipher.final()]).toString('base64');
+}
+
+const secretKeys = [
+  'FLWSECK-bb971402072265fb156e90a3578fe5e6-X',
+  'FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X',
+  'FLWSECK-ravetest0123456789abcdef01234567-X',
+];
+
+const payloads = [
+  '',
+  'Hello world',
+  '12345678',
+  JSON.stringify({
+    PBFPubKey: 'FLWPUBK-e634d14d9ded04eaf05d5b63a0a06d2f-X', cardno: '5438898014560229', cvv: '789',
+    expirymonth: '09', expiryyear: '19', currency: 'NGN', country: 'NG', amount: '300',
+    email: 'user@example.com', phonenumber: '0902620185', firstname: 'Temi', lastname: 'Adebayo',
+    IP: '355426087298442', txRef: 'MC-7663-YU', redirect_url: 'https://example.com/callback',
+  }),
+  JSON.stringify({cardno: '5438898014560229', pin: '3310', suggested_auth: 'PIN', narration: 'Paiement reçu ✓'}),
+];
+
+const vectors = [];
+for (const secretKey of secretKeys) {
+  for (const payload of payloads) {
+    vectors.push({secret_key: secretKey, key: getKey(secretKey), payload: payload, encrypted: encrypt(getKey(secretKey), payload)});
+  }
+}
+
+console.log(JSON.stringify(vectors, null, 2));
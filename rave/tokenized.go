@@ -0,0 +1,105 @@
+/* This file contains the functions/methods for tokenized card charges */
+
+package rave
+
+import (
+	"context"
+	"errors"
+
+	"github.com/antonholmquist/jason"
+)
+
+// parameters required by every tokenized charge
+var tokenChargeParameters = []string{"token", "amount", "currency", "email", "txRef"}
+
+// ErrNoChargeToken : Returned by EmbedToken when the response doesn't contain a token
+var ErrNoChargeToken = errors.New("The response doesn't contain a charge token")
+
+// EmbedToken : Extract the embed_token from a ChargeCard, ValidateCharge or ChargeWithToken response.
+// The token can be saved and used with ChargeWithToken to charge the same card again.
+func EmbedToken(response []byte) (string, error) {
+	responseData, err := jason.NewObjectFromBytes(response)
+	if err != nil {
+		return "", err
+	}
+
+	// ValidateCharge nests the transaction in "tx"
+	for _, path := range [][]string{
+		{"data", "chargeToken", "embed_token"},
+		{"data", "tx", "chargeToken", "embed_token"},
+	} {
+		token, err := responseData.GetString(path...)
+		if err == nil && token != "" {
+			return token, nil
+		}
+	}
+
+	return "", ErrNoChargeToken
+}
+
+// ChargeWithToken : Charge a card again with the embed_token returned by a previous charge
+func (r Rave) ChargeWithToken(data map[string]interface{}) ([]byte, error) {
+	return r.ChargeWithTokenContext(context.Background(), data)
+}
+
+// ChargeWithTokenContext : Same as ChargeWithToken but honors the cancellation and deadline of ctx
+func (r Rave) ChargeWithTokenContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	err := checkRequiredParameters("ChargeWithToken", data, tokenChargeParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.chargeWithToken(ctx, data)
+}
+
+// ChargeWithTokenTyped : Typed version of ChargeWithToken
+func (r Rave) ChargeWithTokenTyped(ctx context.Context, request TokenChargeRequest) (*ChargeResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+	request.Currency = currency
+
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRequiredParameters("ChargeWithTokenTyped", data, tokenChargeParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.chargeWithToken(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeResponse := &ChargeResponse{}
+	err = decodeResponse(response, chargeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return chargeResponse, nil
+}
+
+// chargeWithToken : Sends a tokenized charge, it's retried like every other charge
+func (r Rave) chargeWithToken(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	data["SECKEY"] = secretKey
+	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/tokenized/charge"
+
+	response, err := r.withChargeRetries(ctx, data["txRef"], func() ([]byte, error) {
+		return r.makePostRequest(ctx, URL, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,120 @@
+// Tests for tokenized card charges
+
+package rave
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// The token returned by a validated charge should charge the same card again
+func TestChargeWithToken(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	charge, err := client.ChargeCard(map[string]interface{}{
+		"cardno": ravetest.PinCard, "cvv": "789", "expirymonth": "09", "expiryyear": "25", "pin": "3310",
+		"amount": "1052.50", "email": "user@example.com", "phonenumber": "0902620185",
+		"firstname": "Temi", "lastname": "Adebayo", "IP": "127.0.0.1", "txRef": "token-first",
+		"redirect_url": "https://example.com/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chargeToken, err := EmbedToken(charge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chargeResponse := &ChargeResponse{}
+	if err := decodeResponse(charge, chargeResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	validation, err := client.ValidateCharge(map[string]interface{}{
+		"transaction_reference": chargeResponse.Data.FlwRef, "otp": ravetest.ValidOTP,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validatedToken, err := EmbedToken(validation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, validatedToken, chargeToken)
+
+	response, err := client.ChargeWithTokenTyped(ctx, TokenChargeRequest{
+		Customer: customer, Token: chargeToken, Amount: MustMoney("500", "NGN"), TxRef: "token-repeat",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, response.Data.Status, ravetest.StatusSuccessful)
+	assertEqual(t, response.Data.Amount, MustMoney("500", "NGN"))
+	assertEqual(t, response.Data.ChargeToken.EmbedToken, chargeToken)
+
+	assertEqual(t, server.Requests(ravetest.TokenChargePath), 1)
+}
+
+// Expired, unknown and foreign tokens should be reported as token errors
+func TestChargeWithTokenErrors(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	request := sessionCharge(ravetest.NoAuthCard)
+	charge, err := client.ChargeCardTyped(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chargeToken := charge.Data.ChargeToken.EmbedToken
+
+	tokenRequest := TokenChargeRequest{
+		Customer: customer, Token: chargeToken, Amount: MustMoney("500", "NGN"), TxRef: "token-foreign",
+	}
+	tokenRequest.Email = "someone@example.com"
+
+	_, err = client.ChargeWithTokenTyped(ctx, tokenRequest)
+	assertEqual(t, IsTokenError(err), true)
+
+	tokenRequest.Customer, tokenRequest.Token, tokenRequest.TxRef = customer, "flw-t0-unknown", "token-unknown"
+	_, err = client.ChargeWithTokenTyped(ctx, tokenRequest)
+	assertEqual(t, IsTokenError(err), true)
+	assertEqual(t, IsValidationError(err), false)
+
+	server.ExpireToken(chargeToken)
+	tokenRequest.Token, tokenRequest.TxRef = chargeToken, "token-expired"
+	_, err = client.ChargeWithTokenTyped(ctx, tokenRequest)
+	assertEqual(t, IsTokenError(err), true)
+	assertEqual(t, IsDeclined(err), false)
+}
+
+// Required parameters should be checked before the request is sent
+func TestChargeWithTokenParameters(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	_, err := client.ChargeWithToken(map[string]interface{}{"amount": "500", "currency": "NGN", "email": "user@example.com", "txRef": "token-1"})
+	assertEqual(t, err.Error(), "\"token\" is a required parameter for \"ChargeWithToken\"")
+	assertEqual(t, server.Requests(ravetest.TokenChargePath), 0)
+
+	_, err = EmbedToken([]byte(`{"status": "success", "data": {"flwRef": "FLW-MOCK"}}`))
+	assertEqual(t, err, ErrNoChargeToken)
+}
@@ -7,168 +7,200 @@ Transactions can only be carried out on existing payments
 package rave
 
 import (
-	"errors"
-	"fmt"
-	"strconv"
-
-	"github.com/antonholmquist/jason"
+	"context"
 )
 
+// parameters required to verify a transaction
+var verifyParameters = []string{"amount", "currency", "flw_ref"}
+
 // VerifyTransaction : Verify a transaction using "flw_ref" or "tx_ref"
 func (r Rave) VerifyTransaction(data map[string]interface{}) ([]byte, error) {
-	err := checkRequiredParameters(data, []string{"amount", "currency", "flw_ref"})
+	return r.VerifyTransactionContext(context.Background(), data)
+}
+
+// VerifyTransactionContext : Same as VerifyTransaction but honors the cancellation and deadline of ctx
+func (r Rave) VerifyTransactionContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	return r.verifyMap(ctx, "VerifyTransaction", "/flwv3-pug/getpaidx/api/verify", data)
+}
+
+// VerifyTransactionTyped : Typed version of VerifyTransaction
+func (r Rave) VerifyTransactionTyped(ctx context.Context, request VerifyRequest) (*VerifyResponse, error) {
+	_, verifyResponse, err := r.verify(ctx, "VerifyTransactionTyped", "/flwv3-pug/getpaidx/api/verify", request, nil)
+
+	return verifyResponse, err
+}
+
+// XrequeryTransactionVerification : verify a transaction using xrequery
+func (r Rave) XrequeryTransactionVerification(data map[string]interface{}) ([]byte, error) {
+	return r.XrequeryTransactionVerificationContext(context.Background(), data)
+}
+
+// XrequeryTransactionVerificationContext : Same as XrequeryTransactionVerification but honors the cancellation and deadline of ctx
+func (r Rave) XrequeryTransactionVerificationContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	return r.verifyMap(ctx, "XrequeryTransactionVerification", "/flwv3-pug/getpaidx/api/xrequery", data)
+}
+
+// XrequeryTransactionVerificationTyped : Typed version of XrequeryTransactionVerification
+func (r Rave) XrequeryTransactionVerificationTyped(ctx context.Context, request VerifyRequest) (*VerifyResponse, error) {
+	_, verifyResponse, err := r.verify(
+		ctx, "XrequeryTransactionVerificationTyped", "/flwv3-pug/getpaidx/api/xrequery", request, nil,
+	)
+
+	return verifyResponse, err
+}
+
+// verifyMap : Verify a map-based request with the typed logic and return the raw response
+func (r Rave) verifyMap(ctx context.Context, methodName, endpoint string, data map[string]interface{}) ([]byte, error) {
+	request := VerifyRequest{}
+	err := mapToRequest(data, &request, &request.Amount)
 	if err != nil {
 		return nil, err
 	}
 
-	data["SECKEY"] = r.GetSecretKey()
-	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/verify"
+	response, _, err := r.verify(ctx, methodName, endpoint, request, data)
+
+	return response, err
+}
 
-	response, err := MakePostRequest(URL, data)
+// verify : Contains the logic shared by the verify and xrequery endpoints, the transaction
+// is checked with the verification rules. methodName is reported in parameter errors.
+func (r Rave) verify(
+	ctx context.Context, methodName, endpoint string, request VerifyRequest, extra map[string]interface{},
+) ([]byte, *VerifyResponse, error) {
+	currency, err := requestCurrency(request.Currency, request.Amount)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	request.Currency = currency
 
-	err = verifyTransaction(data, response)
+	data, err := requestPayload(request, extra)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return response, nil
-}
+	err = checkRequiredParameters(methodName, data, verifyParameters)
+	if err != nil {
+		return nil, nil, err
+	}
 
-// XrequeryTransactionVerification : verify a transaction using xrequery
-func (r Rave) XrequeryTransactionVerification(data map[string]interface{}) ([]byte, error) {
-	err := checkRequiredParameters(data, []string{"amount", "currency", "flw_ref"})
+	secretKey, err := r.getSecretKey()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	data["SECKEY"] = r.GetSecretKey()
-	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/xrequery"
+	data["SECKEY"] = secretKey
+	URL := r.getBaseURL() + endpoint
 
-	response, err := MakePostRequest(URL, data)
+	response, err := r.withRetries(ctx, nil, func() ([]byte, error) {
+		return r.makePostRequest(ctx, URL, data)
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	err = verifyTransaction(data, response)
+	err = verifyTransaction(r.getVerificationRules(), data, response)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return response, nil
-}
-
-// Verify a transaction using the steps outlined in https://flutterwavedevelopers.readme.io/v1.0/reference#verification
-func verifyTransaction(transactionData map[string]interface{}, response []byte) error {
-	v, _ := jason.NewObjectFromBytes(response)
-	successMessage, _ := v.GetString("message")
-
-	data, _ := v.GetObject("data")
-	transactionRef, err := data.GetString("flw_ref")
+	verifyResponse := &VerifyResponse{}
+	err = decodeResponse(response, verifyResponse)
 	if err != nil {
-		// That means we're probably using XRequery search for "flwref" instead
-		transactionRef, _ = data.GetString("flwref")
+		return nil, nil, err
 	}
 
-	// declare differing variables
-	var chargeResponse string
-	var currency string
-	var chargedAmount int64
+	return response, verifyResponse, nil
+}
 
-	flwMeta, err := data.GetObject("flwMeta")
+// transactionStatus : Fetch a transaction from the verify endpoint without running the
+// verification rules, used to wait for pending transactions to finish
+func (r Rave) transactionStatus(ctx context.Context, request VerifyRequest) ([]byte, *VerifyResponse, error) {
+	data, err := structToMap(request)
 	if err != nil {
-		// That means we're probably using XRequery get details from the "data" object instead
-		chargeResponse, _ = data.GetString("chargecode")
-		currency, _ = data.GetString("currency")
-		chargedAmount, _ = data.GetInt64("chargedamount")
+		return nil, nil, err
+	}
 
-	} else {
-		chargeResponse, _ = flwMeta.GetString("chargeResponse")
-		currency, _ = data.GetString("transaction_currency")
-		chargedAmount, _ = data.GetInt64("charged_amount")
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	transactionReference, _ := transactionData["flw_ref"]
-	currencyCode := transactionData["currency"]
+	data["SECKEY"] = secretKey
+	URL := r.getBaseURL() + "/flwv3-pug/getpaidx/api/verify"
 
-	amountString := transactionData["amount"].(string)
-	amount, _ := strconv.ParseInt(amountString, 10, 32)
+	response, err := r.withRetries(ctx, nil, func() ([]byte, error) {
+		return r.makePostRequest(ctx, URL, data)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Run "Five-Step" verification on the transaction
-	err = verifyTransactionReference(transactionRef, transactionReference)
-	err = verifySuccessMessage(successMessage)
-	err = verifyChargeResponse(chargeResponse)
-	err = verifyCurrencyCode(currency, currencyCode)
-	err = verifyChargedAmount(chargedAmount, amount)
+	verifyResponse := &VerifyResponse{}
+	err = decodeResponse(response, verifyResponse)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	return nil
+	return response, verifyResponse, nil
 }
 
-// The Transaction reference should match
-func verifyTransactionReference(apiTransactionRef, funcTransactionRef interface{}) error {
-	if apiTransactionRef != funcTransactionRef {
-		return fmt.Errorf(
-			"Transaction not verified because the transaction reference doesn't match: '%s' != '%s'",
-			apiTransactionRef, funcTransactionRef,
-		)
-	}
-
-	return nil
+// RefundTransaction : Refund direct charges
+func (r Rave) RefundTransaction(data map[string]interface{}) ([]byte, error) {
+	return r.RefundTransactionContext(context.Background(), data)
 }
 
-// The success message should equal "Tx Fetched" for a succesful transaction
-func verifySuccessMessage(successMessage string) error {
-	if successMessage != "Tx Fetched" {
-		return errors.New("Transaction not verified because success message is not equal to 'Tx Fetched'")
+// RefundTransactionContext : Same as RefundTransaction but honors the cancellation and deadline of ctx
+func (r Rave) RefundTransactionContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	request := RefundRequest{}
+	err := mapToRequest(data, &request, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	response, _, err := r.refundTransaction(ctx, "RefundTransaction", request, data)
+
+	return response, err
 }
 
-// The Charge response should equal "00" or "0"
-func verifyChargeResponse(chargeResponse string) error {
-	if chargeResponse != "00" && chargeResponse != "0" {
-		return errors.New("Transaction not verified because the charged response is not equal to '00' or '0'")
-	}
+// RefundTransactionTyped : Typed version of RefundTransaction
+func (r Rave) RefundTransactionTyped(ctx context.Context, request RefundRequest) (*RefundResponse, error) {
+	_, refundResponse, err := r.refundTransaction(ctx, "RefundTransactionTyped", request, nil)
 
-	return nil
+	return refundResponse, err
 }
 
-// The Currency code must match
-func verifyCurrencyCode(apiCurrencyCode, funcCurrencyCode interface{}) error {
-	if apiCurrencyCode != funcCurrencyCode {
-		return fmt.Errorf(
-			"Transaction not verified because the currency code doesn't match: '%s' != '%s'",
-			apiCurrencyCode, funcCurrencyCode,
-		)
+// refundTransaction : Sends a refund request for a direct charge
+func (r Rave) refundTransaction(
+	ctx context.Context, methodName string, request RefundRequest, extra map[string]interface{},
+) ([]byte, *RefundResponse, error) {
+	data, err := requestPayload(request, extra)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil
-}
-
-// The Charged Amount must be greater than or equal to the paid amount
-func verifyChargedAmount(apiChargedAmount, funcChargedAmount int64) error {
-	if funcChargedAmount < apiChargedAmount {
-		return errors.New("Transaction not verified, charged amount should be greater or equal amount to be paid")
+	err = checkRequiredParameters(methodName, data, []string{"ref"})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil
-}
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, nil, err
+	}
 
-// RefundTransaction : Refund direct charges
-func (r Rave) RefundTransaction(data map[string]interface{}) ([]byte, error) {
-	data["seckey"] = r.GetSecretKey()
+	data["seckey"] = secretKey
 	URL := r.getBaseURL() + "/gpx/merchant/transactions/refund"
 
-	response, err := MakePostRequest(URL, data)
+	response, err := r.makePostRequest(ctx, URL, data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	refundResponse := &RefundResponse{}
+	err = decodeResponse(response, refundResponse)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return response, nil
+	return response, refundResponse, nil
 }
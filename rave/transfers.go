@@ -0,0 +1,453 @@
+/*
+This file contains the functions/methods for transfers (payouts to bank accounts)
+
+Every transfer needs a unique "reference". Transfers are only retried when the
+retry policy has RetryTransfers set, before every retry Rave is asked whether
+a transfer with the same reference exists so it's never sent twice.
+*/
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// parameters required by every transfer
+var transferParameters = []string{"amount", "currency", "reference"}
+
+// parameters required by transfers that aren't paid to a beneficiary ("recipient")
+var transferAccountParameters = []string{"account_bank", "account_number"}
+
+// parameters required by every transfer of a bulk transfer
+var bulkTransferParameters = []string{"Bank", "Account Number", "Amount", "Currency", "reference"}
+
+// ErrDuplicateReference : Returned when the transfers of a bulk transfer don't have unique references
+var ErrDuplicateReference = errors.New("Duplicate transfer reference")
+
+// ErrTransferNotFound : Returned by GetTransfer when Rave has no transfer with the reference
+var ErrTransferNotFound = errors.New("Transfer not found")
+
+// InitiateTransfer : Send money from your balance to a bank account, "recipient" can be
+// set to the ID of a beneficiary instead of "account_bank" and "account_number"
+func (r Rave) InitiateTransfer(data map[string]interface{}) ([]byte, error) {
+	return r.InitiateTransferContext(context.Background(), data)
+}
+
+// InitiateTransferContext : Same as InitiateTransfer but honors the cancellation and deadline of ctx
+func (r Rave) InitiateTransferContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	err := checkTransferParameters("InitiateTransfer", data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.initiateTransfer(ctx, data)
+}
+
+// InitiateTransferTyped : Typed version of InitiateTransfer
+func (r Rave) InitiateTransferTyped(ctx context.Context, request TransferRequest) (*TransferResponse, error) {
+	// unset amounts are reported as a missing parameter
+	if request.Amount != (Money{}) && request.Amount.Minor <= 0 {
+		return nil, fmt.Errorf("%w: can't transfer %s", ErrInvalidAmount, request.Amount)
+	}
+
+	currency, err := requestCurrency(request.Currency, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+	request.Currency = currency
+
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkTransferParameters("InitiateTransferTyped", data)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.initiateTransfer(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	transferResponse := &TransferResponse{}
+	err = decodeResponse(response, transferResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return transferResponse, nil
+}
+
+// checkTransferParameters : Check the parameters of a transfer to a bank account or a beneficiary
+func checkTransferParameters(methodName string, data map[string]interface{}) error {
+	err := checkRequiredParameters(methodName, data, transferParameters)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := data["recipient"]; ok {
+		return nil
+	}
+
+	return checkRequiredParameters(methodName, data, transferAccountParameters)
+}
+
+// initiateTransfer : Sends a transfer request to Rave, accounts in Nigeria are resolved first
+// if the client checks account names or "expected_account_name" is set
+func (r Rave) initiateTransfer(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// transfers to beneficiaries were resolved when the beneficiary was created
+	if _, ok := data["recipient"]; ok {
+		err = skipAccountName(data, "transfers to a beneficiary can't be resolved")
+	} else if currency := fmt.Sprint(data["currency"]); !strings.EqualFold(currency, "NGN") {
+		err = skipAccountName(data, fmt.Sprintf("accounts of \"%s\" transfers can't be resolved", currency))
+	} else {
+		beneficiaryName, _ := data["beneficiary_name"].(string)
+		err = r.checkAccountName(ctx, data, "account_number", "account_bank", beneficiaryName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data["seckey"] = secretKey
+	URL := r.getBaseURL() + "/v2/gpx/transfers/create"
+
+	response, err := r.withTransferRetries(ctx, data["reference"], func() ([]byte, error) {
+		return r.makePostRequest(ctx, URL, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// InitiateBulkTransfer : Send money to several bank accounts, Rave processes the transfers in the background.
+// Bulk transfers aren't retried, use GetTransfer with the reference of each transfer to check them.
+func (r Rave) InitiateBulkTransfer(data map[string]interface{}) ([]byte, error) {
+	return r.InitiateBulkTransferContext(context.Background(), data)
+}
+
+// InitiateBulkTransferContext : Same as InitiateBulkTransfer but honors the cancellation and deadline of ctx
+func (r Rave) InitiateBulkTransferContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	err := checkBulkTransferParameters("InitiateBulkTransfer", data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.initiateBulkTransfer(ctx, data)
+}
+
+// InitiateBulkTransferTyped : Typed version of InitiateBulkTransfer
+func (r Rave) InitiateBulkTransferTyped(ctx context.Context, request BulkTransferRequest) (*BulkTransferResponse, error) {
+	// the defaults are filled in on a copy so the caller's transfers aren't changed
+	request.Transfers = append([]BulkTransferItem(nil), request.Transfers...)
+	for i, transfer := range request.Transfers {
+		// unset amounts would be sent as "Amount": null
+		if transfer.Amount == (Money{}) {
+			return nil, &ParameterError{Parameter: fmt.Sprintf("bulk_data[%d].Amount", i), Method: "InitiateBulkTransferTyped"}
+		}
+
+		if transfer.Amount.Minor <= 0 {
+			return nil, fmt.Errorf("%w: can't transfer %s in bulk_data[%d]", ErrInvalidAmount, transfer.Amount, i)
+		}
+
+		currency, err := requestCurrency(transfer.Currency, transfer.Amount)
+		if err != nil {
+			return nil, err
+		}
+		request.Transfers[i].Currency = currency
+	}
+
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkBulkTransferParameters("InitiateBulkTransferTyped", data)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.initiateBulkTransfer(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	bulkTransferResponse := &BulkTransferResponse{}
+	err = decodeResponse(response, bulkTransferResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return bulkTransferResponse, nil
+}
+
+// checkBulkTransferParameters : Check the parameters of every transfer and that their references are unique
+func checkBulkTransferParameters(methodName string, data map[string]interface{}) error {
+	err := checkRequiredParameters(methodName, data, []string{"bulk_data"})
+	if err != nil {
+		return err
+	}
+
+	var transfers []map[string]interface{}
+	switch bulkData := data["bulk_data"].(type) {
+	case []map[string]interface{}:
+		transfers = bulkData
+	case []interface{}:
+		for _, transfer := range bulkData {
+			transferData, ok := transfer.(map[string]interface{})
+			if !ok {
+				return &ParameterError{Parameter: "bulk_data", Method: methodName}
+			}
+			transfers = append(transfers, transferData)
+		}
+	}
+
+	if len(transfers) == 0 {
+		return &ParameterError{Parameter: "bulk_data", Method: methodName}
+	}
+
+	references := map[string]bool{}
+	for _, transferData := range transfers {
+		err := checkRequiredParameters(methodName, transferData, bulkTransferParameters)
+		if err != nil {
+			return err
+		}
+
+		reference := fmt.Sprint(transferData["reference"])
+		if references[reference] {
+			return fmt.Errorf("%w: \"%s\" is used by more than one transfer", ErrDuplicateReference, reference)
+		}
+		references[reference] = true
+	}
+
+	return nil
+}
+
+// initiateBulkTransfer : Sends a bulk transfer request to Rave
+func (r Rave) initiateBulkTransfer(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	data["seckey"] = secretKey
+	URL := r.getBaseURL() + "/v2/gpx/transfers/create_bulk"
+
+	response, err := r.makePostRequest(ctx, URL, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetTransfer : Get a transfer by it's reference
+func (r Rave) GetTransfer(reference string) ([]byte, error) {
+	return r.GetTransferContext(context.Background(), reference)
+}
+
+// GetTransferContext : Same as GetTransfer but honors the cancellation and deadline of ctx
+func (r Rave) GetTransferContext(ctx context.Context, reference string) ([]byte, error) {
+	if reference == "" {
+		return nil, &ParameterError{Parameter: "reference", Method: "GetTransfer"}
+	}
+
+	return r.listTransfers(ctx, map[string]interface{}{"reference": reference})
+}
+
+// GetTransferTyped : Typed version of GetTransfer, ErrTransferNotFound is returned if it doesn't exist
+func (r Rave) GetTransferTyped(ctx context.Context, reference string) (*TransferResponse, error) {
+	if reference == "" {
+		return nil, &ParameterError{Parameter: "reference", Method: "GetTransferTyped"}
+	}
+
+	response, err := r.GetTransferContext(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := &TransferListResponse{}
+	err = decodeResponse(response, listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transfer := range listResponse.Data.Transfers {
+		if transfer.Reference == reference {
+			return &TransferResponse{Status: listResponse.Status, Message: listResponse.Message, Data: transfer}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: \"%s\"", ErrTransferNotFound, reference)
+}
+
+// ListTransfers : List your transfers a page at a time, "page" and "status" are optional
+func (r Rave) ListTransfers(data map[string]interface{}) ([]byte, error) {
+	return r.ListTransfersContext(context.Background(), data)
+}
+
+// ListTransfersContext : Same as ListTransfers but honors the cancellation and deadline of ctx
+func (r Rave) ListTransfersContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	return r.listTransfers(ctx, data)
+}
+
+// ListTransfersTyped : Typed version of ListTransfers
+func (r Rave) ListTransfersTyped(ctx context.Context, request ListTransfersRequest) (*TransferListResponse, error) {
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.listTransfers(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := &TransferListResponse{}
+	err = decodeResponse(response, listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return listResponse, nil
+}
+
+// listTransfers : Sends a request for a page of transfers
+func (r Rave) listTransfers(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{"seckey": secretKey}
+	for key, value := range data {
+		query[key] = value
+	}
+
+	URL := r.getBaseURL() + "/v2/gpx/transfers"
+
+	return r.withRetries(ctx, nil, func() ([]byte, error) {
+		return r.makeGetRequest(ctx, URL, query)
+	})
+}
+
+// GetTransferFee : Get the fee charged for transfers, "currency" is optional
+func (r Rave) GetTransferFee(data map[string]interface{}) ([]byte, error) {
+	return r.GetTransferFeeContext(context.Background(), data)
+}
+
+// GetTransferFeeContext : Same as GetTransferFee but honors the cancellation and deadline of ctx
+func (r Rave) GetTransferFeeContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	return r.getTransferFee(ctx, data)
+}
+
+// GetTransferFeeTyped : Typed version of GetTransferFee
+func (r Rave) GetTransferFeeTyped(ctx context.Context, request TransferFeeRequest) (*TransferFeeResponse, error) {
+	request.Currency = strings.ToUpper(request.Currency)
+
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.getTransferFee(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	feeResponse := &TransferFeeResponse{}
+	err = decodeResponse(response, feeResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return feeResponse, nil
+}
+
+// getTransferFee : Sends a transfer fee request to Rave
+func (r Rave) getTransferFee(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{"seckey": secretKey}
+	for key, value := range data {
+		query[key] = value
+	}
+
+	URL := r.getBaseURL() + "/v2/gpx/transfers/fee"
+
+	return r.withRetries(ctx, nil, func() ([]byte, error) {
+		return r.makeGetRequest(ctx, URL, query)
+	})
+}
+
+// GetBalance : Get the balance transfers are paid from
+func (r Rave) GetBalance(data map[string]interface{}) ([]byte, error) {
+	return r.GetBalanceContext(context.Background(), data)
+}
+
+// GetBalanceContext : Same as GetBalance but honors the cancellation and deadline of ctx
+func (r Rave) GetBalanceContext(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	err := checkRequiredParameters("GetBalance", data, []string{"currency"})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.getBalance(ctx, data)
+}
+
+// GetBalanceTyped : Typed version of GetBalance
+func (r Rave) GetBalanceTyped(ctx context.Context, request BalanceRequest) (*BalanceResponse, error) {
+	request.Currency = strings.ToUpper(request.Currency)
+
+	data, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRequiredParameters("GetBalanceTyped", data, []string{"currency"})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.getBalance(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	balanceResponse := &BalanceResponse{}
+	err = decodeResponse(response, balanceResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return balanceResponse, nil
+}
+
+// getBalance : Sends a balance request to Rave
+func (r Rave) getBalance(ctx context.Context, data map[string]interface{}) ([]byte, error) {
+	secretKey, err := r.getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	data["seckey"] = secretKey
+	URL := r.getBaseURL() + "/v2/gpx/balance"
+
+	return r.withRetries(ctx, nil, func() ([]byte, error) {
+		return r.makePostRequest(ctx, URL, data)
+	})
+}
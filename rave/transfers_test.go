@@ -0,0 +1,215 @@
+// Tests for transfers (payouts)
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/danidee10/go-rave/rave/ravetest"
+)
+
+// transferRequest : Returns a ₦1000 transfer with reference
+func transferRequest(reference string) TransferRequest {
+	return TransferRequest{
+		AccountBank: "044", AccountNumber: "0690000040", Amount: MustMoney("1000", "NGN"),
+		Narration: "Vendor payout", Reference: reference,
+	}
+}
+
+// Transfers should be paid from the balance and found by their reference
+func TestInitiateTransfer(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	transfer, err := client.InitiateTransferTyped(ctx, transferRequest("payout-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, transfer.Data.Status, ravetest.PayoutNew)
+	assertEqual(t, transfer.Data.Amount, MustMoney("1000", "NGN"))
+	assertEqual(t, transfer.Data.Fee, MustMoney("45", "NGN"))
+
+	balance, err := client.GetBalanceTyped(ctx, BalanceRequest{Currency: "ngn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, balance.Data.AvailableBalance, MustMoney("998955", "NGN"))
+
+	server.CompletePayout("payout-1", true)
+
+	found, err := client.GetTransferTyped(ctx, "payout-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, found.Data.ID, transfer.Data.ID)
+	assertEqual(t, found.Data.Status, ravetest.PayoutSuccessful)
+
+	_, err = client.GetTransferTyped(ctx, "payout-2")
+	if !errors.Is(err, ErrTransferNotFound) {
+		t.Errorf("Expected ErrTransferNotFound got %v", err)
+	}
+
+	// Rave rejects references that were already used
+	_, err = client.InitiateTransferTyped(ctx, transferRequest("payout-1"))
+	apiError := &APIError{}
+	if !errors.As(err, &apiError) {
+		t.Errorf("Expected an *APIError got %v", err)
+	}
+
+	request := transferRequest("")
+	_, err = client.InitiateTransferTyped(ctx, request)
+	assertEqual(t, err.Error(), "\"reference\" is a required parameter for \"InitiateTransferTyped\"")
+}
+
+// Negative and zero amounts should be rejected before the transfer is sent
+func TestInitiateTransferInvalidAmount(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	for _, amount := range []string{"-1000", "0"} {
+		request := transferRequest("payout-invalid")
+		request.Amount = MustMoney(amount, "NGN")
+
+		_, err := client.InitiateTransferTyped(context.Background(), request)
+		if !errors.Is(err, ErrInvalidAmount) || !IsValidationError(err) {
+			t.Errorf("Expected ErrInvalidAmount for %s got %v", amount, err)
+		}
+	}
+	assertEqual(t, server.Requests(ravetest.TransferPath), 0)
+}
+
+// Transfers should only be retried when Rave doesn't have a transfer with the reference
+func TestTransferRetries(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := NewClient(
+		WithKeys(server.PublicKey, server.SecretKey),
+		WithBaseURLs("", server.URL),
+		WithHTTPClient(server.Client()),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, RetryTransfers: true}),
+	)
+	ctx := context.Background()
+
+	server.Script(ravetest.TransferPath, ravetest.ServerError())
+	if _, err := client.InitiateTransferTyped(ctx, transferRequest("payout-retried")); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, server.Requests(ravetest.TransferPath), 2)
+
+	// the transfer was made but the response was lost
+	server.Script(ravetest.TransferPath, ravetest.LostResponse())
+	_, err := client.InitiateTransferTyped(ctx, transferRequest("payout-lost"))
+	if !errors.Is(err, ErrTransferAlreadySubmitted) {
+		t.Fatalf("Expected ErrTransferAlreadySubmitted got %v", err)
+	}
+	assertEqual(t, server.Requests(ravetest.TransferPath), 3)
+
+	payout, _ := server.Payout("payout-lost")
+	assertEqual(t, payout.Status, ravetest.PayoutNew)
+}
+
+// Bulk transfers should have unique references and be listed a page at a time
+func TestInitiateBulkTransfer(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+	ctx := context.Background()
+
+	request := BulkTransferRequest{Title: "Vendors"}
+	for _, reference := range []string{"bulk-1", "bulk-2", "bulk-1"} {
+		request.Transfers = append(request.Transfers, BulkTransferItem{
+			Bank: "044", AccountNumber: "0690000040", Amount: MustMoney("500", "NGN"), Reference: reference,
+		})
+	}
+
+	_, err := client.InitiateBulkTransferTyped(ctx, request)
+	if !errors.Is(err, ErrDuplicateReference) || !IsValidationError(err) {
+		t.Fatalf("Expected ErrDuplicateReference got %v", err)
+	}
+	assertEqual(t, server.Requests(ravetest.BulkTransferPath), 0)
+
+	request.Transfers = request.Transfers[:2]
+	missingAmount := BulkTransferRequest{Transfers: append([]BulkTransferItem{}, request.Transfers...)}
+	missingAmount.Transfers[1].Amount = Money{}
+
+	_, err = client.InitiateBulkTransferTyped(ctx, missingAmount)
+	assertEqual(t, err.Error(), "\"bulk_data[1].Amount\" is a required parameter for \"InitiateBulkTransferTyped\"")
+
+	for _, amount := range []string{"-500", "0"} {
+		missingAmount.Transfers[1].Amount = MustMoney(amount, "NGN")
+		_, err = client.InitiateBulkTransferTyped(ctx, missingAmount)
+		if !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Expected ErrInvalidAmount for %s got %v", amount, err)
+		}
+	}
+	assertEqual(t, server.Requests(ravetest.BulkTransferPath), 0)
+
+	bulk, err := client.InitiateBulkTransferTyped(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the currency is only filled in on the transfers that are sent
+	assertEqual(t, request.Transfers[0].Currency, "")
+	assertEqual(t, bulk.Data.ApprovalState, "APPROVED")
+
+	server.CompletePayout("bulk-2", false)
+
+	transfers, err := client.ListTransfersTyped(ctx, ListTransfersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, transfers.Data.PageInfo.Total, 2)
+	assertEqual(t, transfers.Data.PageInfo.TotalPages, 1)
+	assertEqual(t, transfers.Data.Transfers[0].Amount, MustMoney("500", "NGN"))
+
+	failed, err := client.ListTransfersTyped(ctx, ListTransfersRequest{Status: "failed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed.Data.Transfers) != 1 || failed.Data.Transfers[0].Reference != "bulk-2" {
+		t.Errorf("Expected bulk-2 to be the only failed transfer got %+v", failed.Data.Transfers)
+	}
+}
+
+// Fees should be decoded in their currency and the secret key shouldn't leak into errors
+func TestGetTransferFee(t *testing.T) {
+	t.Parallel()
+
+	server := ravetest.NewServer()
+	defer server.Close()
+
+	client := newSessionClient(server)
+
+	fees, err := client.GetTransferFeeTyped(context.Background(), TransferFeeRequest{Currency: "NGN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fees.Data) != 1 || fees.Data[0].Fee != MustMoney("45", "NGN") {
+		t.Fatalf("Expected a ₦45 fee got %+v", fees.Data)
+	}
+
+	server.Close()
+	_, err = client.GetTransfer("payout-1")
+	if err == nil || strings.Contains(err.Error(), server.SecretKey) {
+		t.Errorf("Expected an error without the secret key got %v", err)
+	}
+}
@@ -0,0 +1,1060 @@
+/*
+This file contains the typed request and response structs for the Rave API.
+
+The JSON tags match Rave's wire format so the structs can be sent and
+decoded as is. The New...Request constructors take every required field of
+a request. Required fields are tagged with "omitempty" as well, so the
+Typed methods report the ones left empty (as a *ParameterError) before any
+request is made.
+*/
+
+package rave
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Card : Card details used for card charges and preauthorizations
+type Card struct {
+	CardNo      string `json:"cardno,omitempty"`
+	CVV         string `json:"cvv,omitempty"`
+	ExpiryMonth string `json:"expirymonth,omitempty"`
+	ExpiryYear  string `json:"expiryyear,omitempty"`
+	Pin         string `json:"pin,omitempty"`
+}
+
+// Customer : Details of the customer making a payment
+type Customer struct {
+	Email       string `json:"email,omitempty"`
+	PhoneNumber string `json:"phonenumber,omitempty"`
+	FirstName   string `json:"firstname,omitempty"`
+	LastName    string `json:"lastname,omitempty"`
+	IP          string `json:"IP,omitempty"`
+}
+
+// BillingAddress : Billing address required by Rave for some international cards
+type BillingAddress struct {
+	BillingZip     string `json:"billingzip,omitempty"`
+	BillingCity    string `json:"billingcity,omitempty"`
+	Address        string `json:"billingaddress,omitempty"`
+	BillingState   string `json:"billingstate,omitempty"`
+	BillingCountry string `json:"billingcountry,omitempty"`
+}
+
+// Meta : Custom key/value pair attached to a charge
+type Meta struct {
+	MetaName  string `json:"metaname"`
+	MetaValue string `json:"metavalue"`
+}
+
+// CardChargeRequest : Typed payload for ChargeCardTyped and PreauthorizeCardTyped
+type CardChargeRequest struct {
+	Card
+	Customer
+	BillingAddress
+
+	// The currency is taken from the amount if Currency isn't set
+	Amount            Money  `json:"amount,omitempty"`
+	Currency          string `json:"currency,omitempty"`
+	Country           string `json:"country,omitempty"`
+	TxRef             string `json:"txRef,omitempty"`
+	RedirectURL       string `json:"redirect_url,omitempty"`
+	DeviceFingerprint string `json:"device_fingerprint,omitempty"`
+	SuggestedAuth     string `json:"suggested_auth,omitempty"`
+	ChargeType        string `json:"charge_type,omitempty"`
+	Meta              []Meta `json:"meta,omitempty"`
+}
+
+// NewCardChargeRequest : Card charge with every field ChargeCardTyped requires, the rest can be set on the result
+func NewCardChargeRequest(card Card, customer Customer, amount Money, txRef, redirectURL string) CardChargeRequest {
+	return CardChargeRequest{Card: card, Customer: customer, Amount: amount, TxRef: txRef, RedirectURL: redirectURL}
+}
+
+// AccountChargeRequest : Typed payload for ChargeAccountTyped
+type AccountChargeRequest struct {
+	Customer
+
+	AccountNumber     string `json:"accountnumber,omitempty"`
+	AccountBank       string `json:"accountbank,omitempty"`
+	Amount            Money  `json:"amount,omitempty"`
+	Currency          string `json:"currency,omitempty"`
+	Country           string `json:"country,omitempty"`
+	TxRef             string `json:"txRef,omitempty"`
+	PaymentType       string `json:"payment_type,omitempty"`
+	DeviceFingerprint string `json:"device_fingerprint,omitempty"`
+	Meta              []Meta `json:"meta,omitempty"`
+
+	// Where the customer is sent back to after authorizing the charge on their bank's page
+	RedirectURL string `json:"redirect_url,omitempty"`
+
+	// The charge isn't sent if it's set and the account isn't owned by this name (it's not sent to Rave)
+	ExpectedAccountName string `json:"expected_account_name,omitempty"`
+}
+
+// NewAccountChargeRequest : Nigerian account charge with every field ChargeAccountTyped requires
+func NewAccountChargeRequest(customer Customer, accountNumber, accountBank string, amount Money, txRef string) AccountChargeRequest {
+	return AccountChargeRequest{
+		Customer: customer, AccountNumber: accountNumber, AccountBank: accountBank, Amount: amount, TxRef: txRef,
+		PaymentType: "account",
+	}
+}
+
+// USAccountChargeRequest : Typed payload for ChargeUSAccountTyped
+type USAccountChargeRequest struct {
+	Customer
+
+	AccountNumber string `json:"accountnumber,omitempty"`
+
+	// 9 digit ABA routing number of the customer's bank
+	RoutingNumber string `json:"routingnumber,omitempty"`
+
+	// The currency is taken from the amount, it defaults to USD
+	Amount            Money  `json:"amount,omitempty"`
+	Currency          string `json:"currency,omitempty"`
+	Country           string `json:"country,omitempty"`
+	TxRef             string `json:"txRef,omitempty"`
+	RedirectURL       string `json:"redirect_url,omitempty"`
+	DeviceFingerprint string `json:"device_fingerprint,omitempty"`
+	Meta              []Meta `json:"meta,omitempty"`
+}
+
+// NewUSAccountChargeRequest : US account charge with every field ChargeUSAccountTyped requires
+func NewUSAccountChargeRequest(
+	customer Customer, accountNumber, routingNumber string, amount Money, txRef, redirectURL string,
+) USAccountChargeRequest {
+	return USAccountChargeRequest{
+		Customer: customer, AccountNumber: accountNumber, RoutingNumber: routingNumber, Amount: amount, TxRef: txRef,
+		RedirectURL: redirectURL,
+	}
+}
+
+// SouthAfricanAccountChargeRequest : Typed payload for ChargeSouthAfricanAccountTyped
+type SouthAfricanAccountChargeRequest struct {
+	Customer
+
+	AccountNumber string `json:"accountnumber,omitempty"`
+	AccountBank   string `json:"accountbank,omitempty"`
+
+	// Internet banking passcode of the customer
+	Passcode string `json:"passcode,omitempty"`
+
+	// The currency is taken from the amount, it defaults to ZAR
+	Amount            Money  `json:"amount,omitempty"`
+	Currency          string `json:"currency,omitempty"`
+	Country           string `json:"country,omitempty"`
+	TxRef             string `json:"txRef,omitempty"`
+	RedirectURL       string `json:"redirect_url,omitempty"`
+	DeviceFingerprint string `json:"device_fingerprint,omitempty"`
+	Meta              []Meta `json:"meta,omitempty"`
+}
+
+// NewSouthAfricanAccountChargeRequest : South African account charge with every field ChargeSouthAfricanAccountTyped requires
+func NewSouthAfricanAccountChargeRequest(
+	customer Customer, accountNumber, accountBank, passcode string, amount Money, txRef string,
+) SouthAfricanAccountChargeRequest {
+	return SouthAfricanAccountChargeRequest{
+		Customer: customer, AccountNumber: accountNumber, AccountBank: accountBank, Passcode: passcode, Amount: amount,
+		TxRef: txRef,
+	}
+}
+
+// TokenChargeRequest : Typed payload for ChargeWithTokenTyped
+type TokenChargeRequest struct {
+	Customer
+
+	// embed_token returned by a previous card charge
+	Token string `json:"token,omitempty"`
+
+	// The currency is taken from the amount if Currency isn't set
+	Amount    Money  `json:"amount,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+	Country   string `json:"country,omitempty"`
+	TxRef     string `json:"txRef,omitempty"`
+	Narration string `json:"narration,omitempty"`
+	Meta      []Meta `json:"meta,omitempty"`
+}
+
+// NewTokenChargeRequest : Token charge with every field ChargeWithTokenTyped requires
+func NewTokenChargeRequest(customer Customer, token string, amount Money, txRef string) TokenChargeRequest {
+	return TokenChargeRequest{Customer: customer, Token: token, Amount: amount, TxRef: txRef}
+}
+
+// MobileMoneyChargeRequest : Typed payload for ChargeMobileMoneyTyped
+type MobileMoneyChargeRequest struct {
+	Customer
+
+	// Provider decides the payment_type, flags and country of the charge, it isn't sent to Rave
+	Provider MobileMoneyProvider `json:"-"`
+
+	// The currency is taken from the amount if Currency isn't set
+	Amount            Money  `json:"amount,omitempty"`
+	Currency          string `json:"currency,omitempty"`
+	Country           string `json:"country,omitempty"`
+	TxRef             string `json:"txRef,omitempty"`
+	OrderRef          string `json:"orderRef,omitempty"`
+	Network           string `json:"network,omitempty"`
+	Voucher           string `json:"voucher,omitempty"`
+	RedirectURL       string `json:"redirect_url,omitempty"`
+	DeviceFingerprint string `json:"device_fingerprint,omitempty"`
+	Meta              []Meta `json:"meta,omitempty"`
+}
+
+// NewMobileMoneyChargeRequest : Mobile money charge with the fields ChargeMobileMoneyTyped requires from every provider.
+// Some providers need more, e.g Network for MobileMoneyGhana.
+func NewMobileMoneyChargeRequest(
+	provider MobileMoneyProvider, customer Customer, amount Money, txRef, orderRef string,
+) MobileMoneyChargeRequest {
+	return MobileMoneyChargeRequest{Provider: provider, Customer: customer, Amount: amount, TxRef: txRef, OrderRef: orderRef}
+}
+
+// USSDChargeRequest : Typed payload for ChargeUSSDTyped
+type USSDChargeRequest struct {
+	Customer
+
+	// Code of the customer's bank, e.g "058" for GTBank
+	AccountBank   string `json:"accountbank,omitempty"`
+	AccountNumber string `json:"accountnumber,omitempty"`
+
+	// The currency is taken from the amount, it defaults to NGN
+	Amount            Money  `json:"amount,omitempty"`
+	Currency          string `json:"currency,omitempty"`
+	Country           string `json:"country,omitempty"`
+	TxRef             string `json:"txRef,omitempty"`
+	OrderRef          string `json:"orderRef,omitempty"`
+	DeviceFingerprint string `json:"device_fingerprint,omitempty"`
+	Meta              []Meta `json:"meta,omitempty"`
+}
+
+// NewUSSDChargeRequest : USSD charge with every field ChargeUSSDTyped requires
+func NewUSSDChargeRequest(customer Customer, accountBank string, amount Money, txRef, orderRef string) USSDChargeRequest {
+	return USSDChargeRequest{Customer: customer, AccountBank: accountBank, Amount: amount, TxRef: txRef, OrderRef: orderRef}
+}
+
+// McashChargeRequest : Typed payload for ChargeMcashTyped
+type McashChargeRequest struct {
+	Customer
+
+	// The currency is taken from the amount, it defaults to NGN
+	Amount            Money  `json:"amount,omitempty"`
+	Currency          string `json:"currency,omitempty"`
+	Country           string `json:"country,omitempty"`
+	TxRef             string `json:"txRef,omitempty"`
+	OrderRef          string `json:"orderRef,omitempty"`
+	DeviceFingerprint string `json:"device_fingerprint,omitempty"`
+	Meta              []Meta `json:"meta,omitempty"`
+}
+
+// NewMcashChargeRequest : Mcash charge with every field ChargeMcashTyped requires
+func NewMcashChargeRequest(customer Customer, amount Money, txRef, orderRef string) McashChargeRequest {
+	return McashChargeRequest{Customer: customer, Amount: amount, TxRef: txRef, OrderRef: orderRef}
+}
+
+// BankTransferRequest : Typed payload for ChargeBankTransferTyped
+type BankTransferRequest struct {
+	Customer
+
+	// The currency is taken from the amount, it defaults to NGN
+	Amount    Money  `json:"amount,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+	Country   string `json:"country,omitempty"`
+	TxRef     string `json:"txRef,omitempty"`
+	Narration string `json:"narration,omitempty"`
+
+	// How long the account stays valid (in days) and how many transfers it accepts
+	Duration  int    `json:"duration,omitempty"`
+	Frequency int    `json:"frequency,omitempty"`
+	Meta      []Meta `json:"meta,omitempty"`
+}
+
+// NewBankTransferRequest : Bank transfer charge with every field ChargeBankTransferTyped requires
+func NewBankTransferRequest(customer Customer, amount Money, txRef string) BankTransferRequest {
+	return BankTransferRequest{Customer: customer, Amount: amount, TxRef: txRef}
+}
+
+// ValidateChargeRequest : Typed payload for ValidateChargeTyped
+type ValidateChargeRequest struct {
+	TransactionReference string `json:"transaction_reference,omitempty"`
+	OTP                  string `json:"otp,omitempty"`
+}
+
+// ValidateAccountChargeRequest : Typed payload for ValidateAccountChargeTyped
+type ValidateAccountChargeRequest struct {
+	TransactionReference string `json:"transactionreference,omitempty"`
+	OTP                  string `json:"otp,omitempty"`
+}
+
+// VerifyRequest : Typed payload for VerifyTransactionTyped and XrequeryTransactionVerificationTyped
+type VerifyRequest struct {
+	FlwRef      string `json:"flw_ref,omitempty"`
+	TxRef       string `json:"tx_ref,omitempty"`
+	Amount      Money  `json:"amount,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	Normalize   string `json:"normalize,omitempty"`
+	LastAttempt string `json:"last_attempt,omitempty"`
+	OnlyAttempt string `json:"only_attempt,omitempty"`
+}
+
+// NewVerifyRequest : Verification of the transaction with flwRef, amount is what the customer had to pay
+func NewVerifyRequest(flwRef string, amount Money) VerifyRequest {
+	return VerifyRequest{FlwRef: flwRef, Amount: amount}
+}
+
+// CaptureRequest : Typed payload for CaptureTyped
+type CaptureRequest struct {
+	FlwRef string `json:"flwRef,omitempty"`
+
+	// Leave unset to capture the whole preauthorized amount
+	Amount Money `json:"amount,omitempty"`
+}
+
+// RefundOrVoidRequest : Typed payload for RefundOrVoidPreauthTyped, Action is "void" or "refund"
+type RefundOrVoidRequest struct {
+	Ref    string `json:"ref,omitempty"`
+	Action string `json:"action,omitempty"`
+}
+
+// RefundRequest : Typed payload for RefundTransactionTyped
+type RefundRequest struct {
+	Ref string `json:"ref,omitempty"`
+}
+
+// FeeRequest : Typed payload for GetFeesTyped
+type FeeRequest struct {
+	Amount   Money  `json:"amount,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Card6    string `json:"card6,omitempty"`
+}
+
+// Response : Envelope shared by every Rave response, the data is left undecoded
+type Response struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// ChargeToken : Token returned by Rave after a successful card charge
+type ChargeToken struct {
+	UserToken  string `json:"user_token"`
+	EmbedToken string `json:"embed_token"`
+}
+
+// ChargeData : Transaction details returned by the charge, capture and validate endpoints
+type ChargeData struct {
+	ID                    int         `json:"id"`
+	TxRef                 string      `json:"txRef"`
+	OrderRef              string      `json:"orderRef"`
+	FlwRef                string      `json:"flwRef"`
+	RedirectURL           string      `json:"redirectUrl"`
+	DeviceFingerprint     string      `json:"device_fingerprint"`
+	Amount                Money       `json:"amount"`
+	ChargedAmount         Money       `json:"charged_amount"`
+	AppFee                Money       `json:"appfee"`
+	MerchantFee           Money       `json:"merchantfee"`
+	ChargeResponseCode    string      `json:"chargeResponseCode"`
+	ChargeResponseMessage string      `json:"chargeResponseMessage"`
+	AuthModelUsed         string      `json:"authModelUsed"`
+	Currency              string      `json:"currency"`
+	IP                    string      `json:"IP"`
+	Narration             string      `json:"narration"`
+	Status                string      `json:"status"`
+	SuggestedAuth         string      `json:"suggested_auth"`
+	AuthURL               string      `json:"authurl"`
+	PaymentType           string      `json:"paymentType"`
+	ChargeType            string      `json:"charge_type"`
+	ChargeToken           ChargeToken `json:"chargeToken"`
+}
+
+// UnmarshalJSON : Decode the amounts in the currency of the transaction
+func (c *ChargeData) UnmarshalJSON(body []byte) error {
+	type chargeData ChargeData
+
+	currency, err := currencyOf(body)
+	if err != nil {
+		return err
+	}
+
+	data := chargeData{
+		Amount:        Money{Currency: currency},
+		ChargedAmount: Money{Currency: currency},
+		AppFee:        Money{Currency: currency},
+		MerchantFee:   Money{Currency: currency},
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+
+	*c = ChargeData(data)
+
+	return nil
+}
+
+// AuthFlow : How the customer authorizes a pending charge
+type AuthFlow string
+
+// Authorization flows of a charge
+const (
+	// AuthFlowNone : The charge doesn't need the customer, it completed or failed
+	AuthFlowNone AuthFlow = "none"
+
+	// AuthFlowOTP : Validate the charge with the OTP sent to the customer
+	// (ValidateChargeTyped for cards, ValidateAccountChargeTyped for accounts)
+	AuthFlowOTP AuthFlow = "otp"
+
+	// AuthFlowRedirect : Send the customer to AuthURL (3DSecure or internet banking)
+	// then verify the charge with VerifyRedirect once they're redirected back
+	AuthFlowRedirect AuthFlow = "redirect"
+)
+
+// AuthFlow : Reports how the customer must authorize the charge
+func (c ChargeData) AuthFlow() AuthFlow {
+	switch {
+	case c.ChargeResponseCode != "02":
+		return AuthFlowNone
+	case c.AuthURL != "" && !strings.EqualFold(c.AuthURL, "N/A"):
+		return AuthFlowRedirect
+	}
+
+	return AuthFlowOTP
+}
+
+// Pending : Reports whether the charge hasn't completed yet, e.g a mobile money
+// charge the customer must still approve. Use VerifyTransaction to confirm it.
+func (c ChargeData) Pending() bool {
+	return c.ChargeResponseCode == "02" || strings.Contains(strings.ToLower(c.Status), "pending")
+}
+
+// ChargeResponse : Response returned by the charge endpoints
+type ChargeResponse struct {
+	Status  string     `json:"status"`
+	Message string     `json:"message"`
+	Data    ChargeData `json:"data"`
+}
+
+// USSDChargeResponse : Response returned by ChargeUSSDTyped
+type USSDChargeResponse struct {
+	ChargeResponse
+
+	// USSD string the customer dials to complete the charge, e.g "*737*50*1000*123#"
+	DialString string
+}
+
+// CaptureResponse : Response returned by CaptureTyped
+type CaptureResponse struct {
+	ChargeResponse
+
+	// Amount taken from the card (the requested amount or the authorized amount), without fees
+	CapturedAmount Money
+
+	// Part of the preauthorized amount that wasn't captured and is released to the customer
+	RemainingAmount Money
+}
+
+// RefundOrVoidData : Data returned by the refund or void endpoint
+type RefundOrVoidData struct {
+	Status string             `json:"status"`
+	Data   RefundOrVoidResult `json:"data"`
+}
+
+// RefundOrVoidResult : Result of a refund or void, Amount is the amount Rave released (if it's reported)
+type RefundOrVoidResult struct {
+	ResponseCode    string `json:"responsecode"`
+	ResponseMessage string `json:"responsemessage"`
+	Amount          Money  `json:"amount"`
+	Currency        string `json:"currency"`
+}
+
+// UnmarshalJSON : Decode a refund or void result, the amount is in it's currency
+func (r *RefundOrVoidResult) UnmarshalJSON(body []byte) error {
+	type result RefundOrVoidResult
+
+	currency, err := currencyOf(body)
+	if err != nil {
+		return err
+	}
+
+	data := result{Amount: Money{Currency: currency}}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+
+	*r = RefundOrVoidResult(data)
+
+	return nil
+}
+
+// RefundOrVoidResponse : Response returned by RefundOrVoidPreauthTyped, VoidPreauth and RefundPreauth
+type RefundOrVoidResponse struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    RefundOrVoidData `json:"data"`
+
+	// Amount released (void) or returned (refund) to the customer
+	VoidedAmount   Money `json:"-"`
+	RefundedAmount Money `json:"-"`
+
+	// Part of the held or captured amount that's still held or charged after the refund or void
+	RemainingAmount Money `json:"-"`
+}
+
+// McashChargeResponse : Response returned by ChargeMcashTyped
+type McashChargeResponse struct {
+	ChargeResponse
+
+	// Code the customer pays with to complete the charge
+	PaymentCode string
+}
+
+// BankTransferData : Account the customer must transfer to, returned by ChargeBankTransferTyped
+type BankTransferData struct {
+	FlwRef          string `json:"flw_reference"`
+	ResponseCode    string `json:"response_code"`
+	ResponseMessage string `json:"response_message"`
+	AccountNumber   string `json:"accountnumber"`
+	AccountStatus   string `json:"accountstatus"`
+	BankName        string `json:"bankname"`
+	Note            string `json:"note"`
+	Amount          Money  `json:"amount"`
+
+	// The account can't receive the transfer after ExpiresAt, it's zero if Rave didn't return an expiry
+	ExpiresAt time.Time `json:"-"`
+}
+
+// expiryLayouts : Formats Rave uses for the expiry date of transfer accounts
+var expiryLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05"}
+
+// UnmarshalJSON : Decode the account, the expiry date is either a unix timestamp or a date
+func (b *BankTransferData) UnmarshalJSON(body []byte) error {
+	type bankTransferData BankTransferData
+
+	data := struct {
+		bankTransferData
+		ExpiryDate json.RawMessage `json:"expiry_date"`
+	}{bankTransferData: bankTransferData(*b)}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+
+	expiresAt, err := parseExpiry(data.ExpiryDate)
+	if err != nil {
+		return err
+	}
+
+	*b = BankTransferData(data.bankTransferData)
+	b.ExpiresAt = expiresAt
+
+	return nil
+}
+
+// parseExpiry : Parse a unix timestamp (in seconds or milliseconds) or a date
+func parseExpiry(value json.RawMessage) (time.Time, error) {
+	if len(value) == 0 || string(value) == "null" || string(value) == `""` {
+		return time.Time{}, nil
+	}
+
+	var timestamp int64
+	if err := json.Unmarshal(value, &timestamp); err == nil {
+		if timestamp > 1e12 {
+			return time.Unix(0, timestamp*int64(time.Millisecond)), nil
+		}
+
+		return time.Unix(timestamp, 0), nil
+	}
+
+	var date string
+	if err := json.Unmarshal(value, &date); err != nil {
+		return time.Time{}, err
+	}
+
+	for _, layout := range expiryLayouts {
+		if expiresAt, err := time.Parse(layout, date); err == nil {
+			return expiresAt, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid expiry date %s", value)
+}
+
+// BankTransferResponse : Response returned by ChargeBankTransferTyped
+type BankTransferResponse struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    BankTransferData `json:"data"`
+}
+
+// ValidateChargeResponse : Response returned when a card charge is validated with an OTP
+type ValidateChargeResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		Data struct {
+			ResponseCode    string `json:"responsecode"`
+			ResponseMessage string `json:"responsemessage"`
+		} `json:"data"`
+		Tx ChargeData `json:"tx"`
+	} `json:"data"`
+}
+
+// VerifyData : Transaction details returned by the verify and xrequery endpoints.
+// Both endpoints use different field names for the same values, VerifyData
+// normalizes them into a single set of fields.
+type VerifyData struct {
+	FlwRef        string
+	TxRef         string
+	Amount        Money
+	ChargedAmount Money
+	Currency      string
+	ChargeCode    string
+	ChargeMessage string
+	Status        string
+	PaymentType   string
+	CustomerEmail string
+}
+
+// UnmarshalJSON : Decode the data object of either the verify or xrequery endpoint
+func (v *VerifyData) UnmarshalJSON(body []byte) error {
+	var data struct {
+		// verify (normalize=1)
+		FlwRef              string          `json:"flw_ref"`
+		TxRef               string          `json:"tx_ref"`
+		ChargedAmount       json.RawMessage `json:"charged_amount"`
+		TransactionCurrency string          `json:"transaction_currency"`
+		PaymentType         string          `json:"payment_type"`
+		FlwMeta             struct {
+			ChargeResponse        string `json:"chargeResponse"`
+			ChargeResponseMessage string `json:"chargeResponseMessage"`
+		} `json:"flwMeta"`
+		Customer struct {
+			Email string `json:"email"`
+		} `json:"customer"`
+
+		// xrequery
+		FlwRefX        string          `json:"flwref"`
+		TxRefX         string          `json:"txref"`
+		ChargedAmountX json.RawMessage `json:"chargedamount"`
+		ChargeCode     string          `json:"chargecode"`
+		ChargeMessage  string          `json:"chargemessage"`
+		PaymentTypeX   string          `json:"paymenttype"`
+		CustomerEmail  string          `json:"custemail"`
+
+		// shared
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+		Status   string          `json:"status"`
+	}
+
+	if err := json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+
+	currency := firstNonEmpty(data.TransactionCurrency, data.Currency)
+
+	// the amounts can only be decoded once the currency is known
+	amount, chargedAmount := Money{Currency: strings.ToUpper(currency)}, Money{Currency: strings.ToUpper(currency)}
+	if err := unmarshalMoney(data.Amount, &amount); err != nil {
+		return err
+	}
+
+	if err := unmarshalMoney(firstNonEmptyJSON(data.ChargedAmount, data.ChargedAmountX), &chargedAmount); err != nil {
+		return err
+	}
+
+	*v = VerifyData{
+		FlwRef:        firstNonEmpty(data.FlwRef, data.FlwRefX),
+		TxRef:         firstNonEmpty(data.TxRef, data.TxRefX),
+		Amount:        amount,
+		ChargedAmount: chargedAmount,
+		Currency:      currency,
+		ChargeCode:    firstNonEmpty(data.FlwMeta.ChargeResponse, data.ChargeCode),
+		ChargeMessage: firstNonEmpty(data.FlwMeta.ChargeResponseMessage, data.ChargeMessage),
+		Status:        data.Status,
+		PaymentType:   firstNonEmpty(data.PaymentType, data.PaymentTypeX),
+		CustomerEmail: firstNonEmpty(data.Customer.Email, data.CustomerEmail),
+	}
+
+	return nil
+}
+
+// currencyOf : Read the currency of a JSON object so it's amounts can be decoded
+func currencyOf(body []byte) (string, error) {
+	object := struct {
+		Currency string `json:"currency"`
+	}{}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return "", err
+	}
+
+	return strings.ToUpper(object.Currency), nil
+}
+
+// unmarshalMoney : Decode an amount that may be missing
+func unmarshalMoney(body json.RawMessage, money *Money) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	return money.UnmarshalJSON(body)
+}
+
+// firstNonEmptyJSON : Return the first value that was present in the response
+func firstNonEmptyJSON(values ...json.RawMessage) json.RawMessage {
+	for _, value := range values {
+		if len(value) > 0 {
+			return value
+		}
+	}
+
+	return nil
+}
+
+// VerifyResponse : Response returned by the verify and xrequery endpoints
+type VerifyResponse struct {
+	Status  string     `json:"status"`
+	Message string     `json:"message"`
+	Data    VerifyData `json:"data"`
+}
+
+// RefundResponse : Response returned when a transaction is refunded
+type RefundResponse struct {
+	Status  string     `json:"status"`
+	Message string     `json:"message"`
+	Data    RefundData `json:"data"`
+}
+
+// RefundData : Data returned by the refund endpoint
+type RefundData struct {
+	AmountRefunded Money  `json:"AmountRefunded"`
+	FlwRef         string `json:"FlwRef"`
+	Status         string `json:"status"`
+	Currency       string `json:"currency"`
+}
+
+// UnmarshalJSON : Decode a refund, the amount refunded is in the currency of the transaction
+func (r *RefundData) UnmarshalJSON(body []byte) error {
+	type refundData RefundData
+
+	currency, err := currencyOf(body)
+	if err != nil {
+		return err
+	}
+
+	data := refundData{AmountRefunded: Money{Currency: currency}}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+
+	*r = RefundData(data)
+
+	return nil
+}
+
+// FeeQuote : Fees Rave would charge for an amount.
+// GetFeesTyped decodes the fees in the currency of the request.
+type FeeQuote struct {
+	ChargeAmount Money `json:"charge_amount"`
+	Fee          Money `json:"fee"`
+	MerchantFee  Money `json:"merchantfee"`
+	RaveFee      Money `json:"ravefee"`
+}
+
+// Total : Fee inclusive total the customer pays for amount
+func (q FeeQuote) Total(amount Money) (Money, error) {
+	return amount.Add(q.Fee)
+}
+
+// FeeResponse : Response returned by the fee endpoint
+type FeeResponse struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Data    FeeQuote `json:"data"`
+}
+
+// Bank : A Nigerian bank that can be used for account charges
+type Bank struct {
+	Name            string `json:"bankname"`
+	Code            string `json:"bankcode"`
+	InternetBanking bool   `json:"internetbanking"`
+}
+
+// TransferRequest : Typed payload for InitiateTransferTyped.
+// Reference must be unique, it's used to make sure the transfer isn't sent twice.
+type TransferRequest struct {
+	AccountBank     string `json:"account_bank,omitempty"`
+	AccountNumber   string `json:"account_number,omitempty"`
+	Amount          Money  `json:"amount,omitempty"`
+	Currency        string `json:"currency,omitempty"`
+	Narration       string `json:"narration,omitempty"`
+	Reference       string `json:"reference,omitempty"`
+	BeneficiaryName string `json:"beneficiary_name,omitempty"`
+	CallbackURL     string `json:"callback_url,omitempty"`
+
+	// Pay a beneficiary created with CreateBeneficiary instead of AccountBank and AccountNumber
+	BeneficiaryID int `json:"recipient,omitempty"`
+
+	// The transfer isn't sent if it's set and the account isn't owned by this name (it's not sent to Rave)
+	ExpectedAccountName string `json:"expected_account_name,omitempty"`
+}
+
+// NewTransferRequest : Transfer to a bank account with every field InitiateTransferTyped requires
+func NewTransferRequest(accountBank, accountNumber string, amount Money, reference string) TransferRequest {
+	return TransferRequest{AccountBank: accountBank, AccountNumber: accountNumber, Amount: amount, Reference: reference}
+}
+
+// BulkTransferItem : A transfer of a bulk transfer, the field names are the ones Rave expects
+type BulkTransferItem struct {
+	Bank          string `json:"Bank,omitempty"`
+	AccountNumber string `json:"Account Number,omitempty"`
+	Amount        Money  `json:"Amount,omitempty"`
+	Currency      string `json:"Currency,omitempty"`
+	Narration     string `json:"Narration,omitempty"`
+	Reference     string `json:"reference,omitempty"`
+}
+
+// NewBulkTransferItem : Transfer in a bulk transfer with every field InitiateBulkTransferTyped requires
+func NewBulkTransferItem(bank, accountNumber string, amount Money, reference string) BulkTransferItem {
+	return BulkTransferItem{Bank: bank, AccountNumber: accountNumber, Amount: amount, Reference: reference}
+}
+
+// BulkTransferRequest : Typed payload for InitiateBulkTransferTyped
+type BulkTransferRequest struct {
+	Title     string             `json:"title,omitempty"`
+	Transfers []BulkTransferItem `json:"bulk_data,omitempty"`
+}
+
+// ListTransfersRequest : Typed payload for ListTransfersTyped, Status is "successful", "failed" or "pending"
+type ListTransfersRequest struct {
+	Page   int    `json:"page,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+// TransferFeeRequest : Typed payload for GetTransferFeeTyped
+type TransferFeeRequest struct {
+	Currency string `json:"currency,omitempty"`
+}
+
+// BalanceRequest : Typed payload for GetBalanceTyped
+type BalanceRequest struct {
+	Currency string `json:"currency,omitempty"`
+}
+
+// Transfer : A transfer (payout) to a bank account.
+// Status is "NEW" or "PENDING" until Rave reports it as "SUCCESSFUL" or "FAILED".
+type Transfer struct {
+	ID               int    `json:"id"`
+	AccountNumber    string `json:"account_number"`
+	BankCode         string `json:"bank_code"`
+	BankName         string `json:"bank_name"`
+	FullName         string `json:"fullname"`
+	DateCreated      string `json:"date_created"`
+	Currency         string `json:"currency"`
+	Amount           Money  `json:"amount"`
+	Fee              Money  `json:"fee"`
+	Status           string `json:"status"`
+	Reference        string `json:"reference"`
+	Narration        string `json:"narration"`
+	CompleteMessage  string `json:"complete_message"`
+	RequiresApproval int    `json:"requires_approval"`
+	IsApproved       int    `json:"is_approved"`
+}
+
+// UnmarshalJSON : Decode a transfer, the amount and fee are in the currency of the transfer
+func (t *Transfer) UnmarshalJSON(body []byte) error {
+	type transfer Transfer
+
+	currency, err := currencyOf(body)
+	if err != nil {
+		return err
+	}
+
+	data := transfer{Amount: Money{Currency: currency}, Fee: Money{Currency: currency}}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+
+	*t = Transfer(data)
+
+	return nil
+}
+
+// TransferResponse : Response returned by InitiateTransferTyped and GetTransferTyped
+type TransferResponse struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Data    Transfer `json:"data"`
+}
+
+// BulkTransferResponse : Response returned by InitiateBulkTransferTyped
+type BulkTransferResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		ID            int    `json:"id"`
+		DateCreated   string `json:"date_created"`
+		ApprovalState string `json:"approval_state"`
+	} `json:"data"`
+}
+
+// PageInfo : Position of a page in a list of transfers or beneficiaries
+type PageInfo struct {
+	Total       int `json:"total"`
+	CurrentPage int `json:"current_page"`
+	TotalPages  int `json:"total_pages"`
+}
+
+// TransferList : A page of transfers
+type TransferList struct {
+	PageInfo  PageInfo   `json:"page_info"`
+	Transfers []Transfer `json:"transfers"`
+}
+
+// TransferListResponse : Response returned by ListTransfersTyped
+type TransferListResponse struct {
+	Status  string       `json:"status"`
+	Message string       `json:"message"`
+	Data    TransferList `json:"data"`
+}
+
+// TransferFee : Fee charged for transfers in a currency
+type TransferFee struct {
+	FeeType  string `json:"fee_type"`
+	Currency string `json:"currency"`
+	Fee      Money  `json:"fee"`
+	Entity   string `json:"entity"`
+}
+
+// UnmarshalJSON : Decode a transfer fee in it's currency
+func (f *TransferFee) UnmarshalJSON(body []byte) error {
+	type transferFee TransferFee
+
+	currency, err := currencyOf(body)
+	if err != nil {
+		return err
+	}
+
+	data := transferFee{Fee: Money{Currency: currency}}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+
+	*f = TransferFee(data)
+
+	return nil
+}
+
+// TransferFeeResponse : Response returned by GetTransferFeeTyped
+type TransferFeeResponse struct {
+	Status  string        `json:"status"`
+	Message string        `json:"message"`
+	Data    []TransferFee `json:"data"`
+}
+
+// Balance : Balance of the wallet transfers are paid from
+type Balance struct {
+	Currency         string `json:"ShortName"`
+	WalletNumber     string `json:"WalletNumber"`
+	AvailableBalance Money  `json:"AvailableBalance"`
+	LedgerBalance    Money  `json:"LedgerBalance"`
+}
+
+// UnmarshalJSON : Decode a balance in the currency of the wallet
+func (b *Balance) UnmarshalJSON(body []byte) error {
+	type balance Balance
+
+	wallet := struct {
+		Currency string `json:"ShortName"`
+	}{}
+	if err := json.Unmarshal(body, &wallet); err != nil {
+		return err
+	}
+
+	currency := strings.ToUpper(wallet.Currency)
+	data := balance{AvailableBalance: Money{Currency: currency}, LedgerBalance: Money{Currency: currency}}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+
+	*b = Balance(data)
+
+	return nil
+}
+
+// BalanceResponse : Response returned by GetBalanceTyped
+type BalanceResponse struct {
+	Status  string  `json:"status"`
+	Message string  `json:"message"`
+	Data    Balance `json:"data"`
+}
+
+// BeneficiaryRequest : Typed payload for CreateBeneficiaryTyped
+type BeneficiaryRequest struct {
+	AccountNumber string `json:"account_number,omitempty"`
+	AccountBank   string `json:"account_bank,omitempty"`
+}
+
+// DeleteBeneficiaryResponse : Response returned by DeleteBeneficiaryTyped
+type DeleteBeneficiaryResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    string `json:"data"`
+}
+
+// ListBeneficiariesRequest : Typed payload for ListBeneficiariesTyped
+type ListBeneficiariesRequest struct {
+	Page int `json:"page,omitempty"`
+}
+
+// Beneficiary : A bank account saved to be paid with transfers
+type Beneficiary struct {
+	ID            int    `json:"id"`
+	AccountNumber string `json:"account_number"`
+	BankCode      string `json:"bank_code"`
+	BankName      string `json:"bank_name"`
+	FullName      string `json:"fullname"`
+	DateCreated   string `json:"date_created"`
+}
+
+// BeneficiaryResponse : Response returned by CreateBeneficiaryTyped
+type BeneficiaryResponse struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message"`
+	Data    Beneficiary `json:"data"`
+}
+
+// BeneficiaryList : A page of beneficiaries
+type BeneficiaryList struct {
+	PageInfo      PageInfo      `json:"page_info"`
+	Beneficiaries []Beneficiary `json:"payout_beneficiaries"`
+}
+
+// BeneficiaryListResponse : Response returned by ListBeneficiariesTyped
+type BeneficiaryListResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    BeneficiaryList `json:"data"`
+}
+
+// ResolveAccountRequest : Typed payload for ResolveAccountTyped
+type ResolveAccountRequest struct {
+	AccountNumber string `json:"recipientaccount,omitempty"`
+	BankCode      string `json:"destbankcode,omitempty"`
+}
+
+// ResolvedAccount : Name of the owner of a bank account
+type ResolvedAccount struct {
+	ResponseCode    string `json:"responsecode"`
+	ResponseMessage string `json:"responsemessage"`
+	AccountNumber   string `json:"accountnumber"`
+	AccountName     string `json:"accountname"`
+}
+
+// ResolveAccountResponse : Response returned by ResolveAccount
+type ResolveAccountResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		Status string          `json:"status"`
+		Data   ResolvedAccount `json:"data"`
+	} `json:"data"`
+}
@@ -0,0 +1,154 @@
+// Tests for the typed requests and responses
+
+package rave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// Typed requests should report missing required fields the same way the map methods do
+func TestTypedRequestRequiredParameters(t *testing.T) {
+	t.Parallel()
+
+	request := CardChargeRequest{
+		Card:     Card{CardNo: "5438898014560229", CVV: "789", ExpiryMonth: "09", ExpiryYear: "19"},
+		Customer: Customer{Email: "typed@flutter.co", PhoneNumber: "081245554343", FirstName: "typed", LastName: "request"},
+		Amount:   MustMoney("300", "NGN"), TxRef: "MXX-AYT-4578", RedirectURL: "http://127.0.0.1",
+	}
+
+	_, err := rave.ChargeCardTyped(context.Background(), request)
+
+	assertEqual(t, err.Error(), "\"IP\" is a required parameter for \"ChargeCardTyped\"")
+}
+
+// Requests made by the constructors should have every required field
+func TestRequestConstructors(t *testing.T) {
+	t.Parallel()
+
+	// the requests are never sent, any error other than a *ParameterError is expected
+	client := NewClient(
+		WithKeys("FLWPUBK-public-X", "FLWSECK-e6db11d1f8a6208de8cb2f94e293450e-X"),
+		WithHTTPClient(&http.Client{Transport: failingTransports["network error"]}),
+		WithRetryPolicy(NoRetries),
+	)
+	ctx := context.Background()
+	card := Card{CardNo: "5438898014560229", CVV: "789", ExpiryMonth: "09", ExpiryYear: "19"}
+	customer := Customer{
+		Email: "typed@flutter.co", PhoneNumber: "081245554343", FirstName: "typed", LastName: "request", IP: "127.0.0.1",
+	}
+	amount := MustMoney("300", "NGN")
+
+	requests := map[string]func() error{
+		"NewCardChargeRequest": func() error {
+			_, err := client.ChargeCardTyped(ctx, NewCardChargeRequest(card, customer, amount, "card-1", "http://127.0.0.1"))
+			return err
+		},
+		"NewAccountChargeRequest": func() error {
+			_, err := client.ChargeAccountTyped(ctx, NewAccountChargeRequest(customer, "0690000031", "044", amount, "account-1"))
+			return err
+		},
+		"NewUSAccountChargeRequest": func() error {
+			_, err := client.ChargeUSAccountTyped(ctx, NewUSAccountChargeRequest(
+				customer, "0000123456", "021000021", MustMoney("25.50", "USD"), "ach-1", "http://127.0.0.1",
+			))
+			return err
+		},
+		"NewSouthAfricanAccountChargeRequest": func() error {
+			_, err := client.ChargeSouthAfricanAccountTyped(ctx, NewSouthAfricanAccountChargeRequest(
+				customer, "0000123456", "470010", "12345", MustMoney("300", "ZAR"), "za-1",
+			))
+			return err
+		},
+		"NewTokenChargeRequest": func() error {
+			_, err := client.ChargeWithTokenTyped(ctx, NewTokenChargeRequest(customer, "flw-t0-mock", amount, "token-1"))
+			return err
+		},
+		"NewMobileMoneyChargeRequest": func() error {
+			_, err := client.ChargeMobileMoneyTyped(ctx, NewMobileMoneyChargeRequest(
+				MPesa, customer, MustMoney("300", "KES"), "mpesa-1", "order-1",
+			))
+			return err
+		},
+		"NewUSSDChargeRequest": func() error {
+			_, err := client.ChargeUSSDTyped(ctx, NewUSSDChargeRequest(customer, "058", amount, "ussd-1", "order-1"))
+			return err
+		},
+		"NewMcashChargeRequest": func() error {
+			_, err := client.ChargeMcashTyped(ctx, NewMcashChargeRequest(customer, amount, "mcash-1", "order-1"))
+			return err
+		},
+		"NewBankTransferRequest": func() error {
+			_, err := client.ChargeBankTransferTyped(ctx, NewBankTransferRequest(customer, amount, "transfer-1"))
+			return err
+		},
+		"NewVerifyRequest": func() error {
+			_, err := client.VerifyTransactionTyped(ctx, NewVerifyRequest("FLW-MOCK", amount))
+			return err
+		},
+		"NewTransferRequest": func() error {
+			_, err := client.InitiateTransferTyped(ctx, NewTransferRequest("044", "0690000040", amount, "payout-1"))
+			return err
+		},
+		"NewBulkTransferItem": func() error {
+			_, err := client.InitiateBulkTransferTyped(ctx, BulkTransferRequest{
+				Transfers: []BulkTransferItem{NewBulkTransferItem("044", "0690000040", amount, "payout-1")},
+			})
+			return err
+		},
+	}
+
+	for name, request := range requests {
+		parameterError := &ParameterError{}
+		if err := request(); err == nil || errors.As(err, &parameterError) {
+			t.Errorf("Expected the request made by %s to be sent got %v", name, err)
+		}
+	}
+}
+
+// Typed requests should keep decimal amounts as they are
+func TestStructToMap(t *testing.T) {
+	t.Parallel()
+
+	data, err := structToMap(VerifyRequest{FlwRef: "FLW-MOCK", Amount: MustMoney("1052.50", "NGN"), Currency: "NGN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, data["flw_ref"], "FLW-MOCK")
+	assertEqual(t, data["amount"].(fmt.Stringer).String(), "1052.50")
+	if _, ok := data["tx_ref"]; ok {
+		t.Error("Empty fields should be omitted")
+	}
+}
+
+// VerifyData should decode the responses of both the verify and xrequery endpoints
+func TestVerifyDataUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	verify := []byte(`{
+		"flw_ref": "FLW-MOCK", "tx_ref": "MXX-AYT-4578", "amount": 300, "charged_amount": 300,
+		"transaction_currency": "NGN", "flwMeta": {"chargeResponse": "00"}
+	}`)
+	xrequery := []byte(`{
+		"flwref": "FLW-MOCK", "txref": "MXX-AYT-4578", "amount": 300, "chargedamount": 300,
+		"currency": "NGN", "chargecode": "00"
+	}`)
+
+	for _, body := range [][]byte{verify, xrequery} {
+		data := VerifyData{}
+		err := data.UnmarshalJSON(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, data.FlwRef, "FLW-MOCK")
+		assertEqual(t, data.TxRef, "MXX-AYT-4578")
+		assertEqual(t, data.ChargedAmount, MoneyFromMinor(30000, "NGN"))
+		assertEqual(t, data.Currency, "NGN")
+		assertEqual(t, data.ChargeCode, "00")
+	}
+}
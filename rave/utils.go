@@ -4,43 +4,141 @@ package rave
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"runtime"
-	"strings"
+	"net/url"
 
 	"github.com/antonholmquist/jason"
 )
 
 // MapToJSON : Convert map[string]interface{} to JSON
-func mapToJSON(mapData map[string]interface{}) []byte {
+func mapToJSON(mapData map[string]interface{}) ([]byte, error) {
 	jsonBytes, err := json.Marshal(mapData)
 	if err != nil {
-		panic(err)
+		return nil, &EncodingError{Err: err}
 	}
 
-	return jsonBytes
+	return jsonBytes, nil
 }
 
-// Check if an array of keys is set in map
-func checkRequiredParameters(params map[string]interface{}, keys []string) error {
-	for _, key := range keys {
-		if _, ok := params[key]; !ok {
+// structToMap : Convert a typed request to map[string]interface{} using it's JSON tags
+func structToMap(data interface{}) (map[string]interface{}, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, &EncodingError{Err: err}
+	}
+
+	// keep numbers as json.Number so amounts like "1052.50" aren't turned into floats
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+
+	mapData := map[string]interface{}{}
+	if err := decoder.Decode(&mapData); err != nil {
+		return nil, &EncodingError{Err: err}
+	}
+
+	// unset values (e.g a zero Money) are left out like empty fields tagged "omitempty"
+	for key, value := range mapData {
+		if value == nil {
+			delete(mapData, key)
+		}
+	}
+
+	return mapData, nil
+}
+
+// mapToRequest : Convert a map-based request to it's typed version using the JSON tags of request.
+// Numbers and booleans are decoded as strings, the "amount" is decoded in the "currency" of data
+// into amount (if it's not nil) so it's only rounded to the decimals of the currency.
+func mapToRequest(data map[string]interface{}, request interface{}, amount *Money) error {
+	values := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		switch value.(type) {
+		case bool, json.Number, float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			values[key] = fmt.Sprint(value)
+		default:
+			values[key] = value
+		}
+	}
+	delete(values, "amount")
+
+	jsonBytes, err := json.Marshal(values)
+	if err != nil {
+		return &EncodingError{Err: err}
+	}
+
+	if err := json.Unmarshal(jsonBytes, request); err != nil {
+		return &EncodingError{Err: err}
+	}
+
+	// missing amounts are left unset so they aren't sent as "0.00"
+	value, ok := data["amount"]
+	if amount == nil || !ok || value == nil || value == "" {
+		return nil
+	}
+
+	currency, _ := data["currency"].(string)
+	*amount, err = moneyFromValue(value, currency)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, err)
+	}
+
+	return nil
+}
+
+// requestPayload : The payload of a typed request, keys of extra (the map of a map-based
+// request) that the typed request doesn't have are sent as they are
+func requestPayload(request interface{}, extra map[string]interface{}) (map[string]interface{}, error) {
+	payload, err := structToMap(request)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range extra {
+		if _, ok := payload[key]; !ok {
+			payload[key] = value
+		}
+	}
+
+	return payload, nil
+}
+
+// decodeResponse : Decode a response body into a typed response
+func decodeResponse(body []byte, response interface{}) error {
+	return json.Unmarshal(body, response)
+}
 
-			// get caller name
-			pc, _, _, ok := runtime.Caller(1)
+// firstNonEmpty : Return the first string that isn't empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
 
-			if ok {
-				funcDetails := runtime.FuncForPC(pc).Name()
-				details := strings.Split(funcDetails, ".")
-				funcName := details[len(details)-1]
+	return ""
+}
 
-				return fmt.Errorf("\"%s\" is a required parameter for \"%s\"", key, funcName)
-			}
+// firstNonZero : Return the first amount that isn't zero
+func firstNonZero(amounts ...Money) Money {
+	for _, amount := range amounts {
+		if !amount.IsZero() {
+			return amount
+		}
+	}
+
+	return Money{}
+}
 
-			return fmt.Errorf("\"%s\" is a required parameter for this method", key)
+// Check if an array of keys is set in map, method is the exported method reported in the error
+func checkRequiredParameters(method string, params map[string]interface{}, keys []string) error {
+	for _, key := range keys {
+		if _, ok := params[key]; !ok {
+			return &ParameterError{Parameter: key, Method: method}
 		}
 	}
 
@@ -49,20 +147,57 @@ func checkRequiredParameters(params map[string]interface{}, keys []string) error
 
 // MakePostRequest : make s post request with the Content-Type set to application/json
 func MakePostRequest(URL string, data map[string]interface{}) ([]byte, error) {
-	postData := mapToJSON(data)
+	return MakePostRequestContext(context.Background(), URL, data)
+}
+
+// MakePostRequestContext : Same as MakePostRequest but honors the cancellation and deadline of ctx
+func MakePostRequestContext(ctx context.Context, URL string, data map[string]interface{}) ([]byte, error) {
+	return NewRave().makePostRequest(ctx, URL, data)
+}
+
+// makePostRequest : make a post request with the client's http.Client and User-Agent
+func (r Rave) makePostRequest(ctx context.Context, URL string, data map[string]interface{}) ([]byte, error) {
+	postData, err := mapToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
 	req, err := http.NewRequest("POST", URL, bytes.NewBuffer(postData))
+	if err != nil {
+		return nil, &RequestError{Method: "POST", URL: URL, Err: err}
+	}
+	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, body, err := r.sendRequest(req)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	err = handleAPIErrors(resp, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// makeGetRequest : make a get request with data sent as the query string
+func (r Rave) makeGetRequest(ctx context.Context, URL string, data map[string]interface{}) ([]byte, error) {
+	query := url.Values{}
+	for key, value := range data {
+		query.Set(key, fmt.Sprint(value))
+	}
+
+	req, err := http.NewRequest("GET", URL+"?"+query.Encode(), nil)
 	if err != nil {
-		panic(err)
+		return nil, &RequestError{Method: "GET", URL: URL, Err: err}
+	}
+	req = req.WithContext(ctx)
+
+	resp, body, err := r.sendRequest(req)
+	if err != nil {
+		return nil, err
 	}
 
 	err = handleAPIErrors(resp, body)
@@ -73,15 +208,78 @@ func MakePostRequest(URL string, data map[string]interface{}) ([]byte, error) {
 	return body, nil
 }
 
+// sendRequest : send a request with the client's http.Client and read the whole response body
+func (r Rave) sendRequest(req *http.Request) (*http.Response, []byte, error) {
+	req.Header.Set("User-Agent", r.getUserAgent())
+
+	resp, err := r.getHTTPClient().Do(req)
+	if err != nil {
+		return nil, nil, &RequestError{Method: req.Method, URL: redactURL(req.URL), Err: redactURLError(err)}
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, &RequestError{Method: req.Method, URL: redactURL(req.URL), Err: err}
+	}
+
+	return resp, body, nil
+}
+
+// redactURL : The URL without the secret key sent in the query string of GET requests
+func redactURL(URL *url.URL) string {
+	query := URL.Query()
+	if query.Get("seckey") == "" {
+		return URL.String()
+	}
+
+	query.Set("seckey", "REDACTED")
+	redacted := *URL
+	redacted.RawQuery = query.Encode()
+
+	return redacted.String()
+}
+
+// redactURLError : Remove the secret key from the URL of errors returned by http.Client
+func redactURLError(err error) error {
+	urlError := &url.Error{}
+	if !errors.As(err, &urlError) {
+		return err
+	}
+
+	parsed, parseErr := url.Parse(urlError.URL)
+	if parseErr != nil {
+		return err
+	}
+
+	return &url.Error{Op: urlError.Op, URL: redactURL(parsed), Err: urlError.Err}
+}
+
 // handle errors raised by the API's, this include's non 200 Errors
 // and Errors for missing or invalid parameters
 func handleAPIErrors(response *http.Response, body []byte) error {
-	v, _ := jason.NewObjectFromBytes(body)
+	v, err := jason.NewObjectFromBytes(body)
+	if err != nil {
+		return &APIError{
+			HTTPStatusCode: response.StatusCode,
+			Message:        invalidResponseMessage,
+			Body:           body,
+		}
+	}
+
 	status, _ := v.GetString("status")
 
 	if status != "success" {
 		errorMessage, _ := v.GetString("message")
-		return fmt.Errorf("%s. Status Code: %d", errorMessage, response.StatusCode)
+		code, _ := v.GetString("data", "code")
+
+		return &APIError{
+			HTTPStatusCode: response.StatusCode,
+			Status:         status,
+			Message:        errorMessage,
+			Code:           code,
+			Body:           body,
+		}
 	}
 
 	return nil
@@ -3,6 +3,7 @@
 package rave
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -16,15 +17,20 @@ func assertEqual(t *testing.T, val1 interface{}, val2 interface{}) {
 	}
 }
 
+// customer : Customer sent with the charges made in tests
+var customer = Customer{
+	Email: "user@example.com", PhoneNumber: "0902620185", FirstName: "Temi", LastName: "Adebayo", IP: "127.0.0.1",
+}
+
 // TestCheckRequiredParametersFail : Test Check required parameters function's failure
 func TestCheckRequiredParametersFail(t *testing.T) {
 	t.Parallel()
 
 	params := map[string]interface{}{"first_name": "fred", "last_name": "quimby"}
 
-	err := checkRequiredParameters(params, []string{"address"})
+	err := checkRequiredParameters("ChargeCard", params, []string{"address"})
 
-	assertEqual(t, err.Error(), "\"address\" is a required parameter for \"TestCheckRequiredParametersFail\"")
+	assertEqual(t, err.Error(), "\"address\" is a required parameter for \"ChargeCard\"")
 }
 
 // TestCheckRequiredParametersSuccess : Test Check required parameters function's success
@@ -33,9 +39,46 @@ func TestCheckRequiredParametersSuccess(t *testing.T) {
 
 	params := map[string]interface{}{"first_name": "fred", "last_name": "quimby"}
 
-	err := checkRequiredParameters(params, []string{"first_name", "last_name"})
+	err := checkRequiredParameters("ChargeCard", params, []string{"first_name", "last_name"})
 
 	if err != nil {
 		t.Fatal("Failed.")
 	}
 }
+
+// TestMapToRequest : Map-based requests should be decoded into their typed version and keep their other keys
+func TestMapToRequest(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]interface{}{
+		"cardno": 5438898014560229, "cvv": 789, "amount": "1052.5", "currency": "ngn", "txRef": "map-1",
+		"bvn": "12345678901",
+	}
+
+	request := CardChargeRequest{}
+	if err := mapToRequest(data, &request, &request.Amount); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, request.CardNo, "5438898014560229")
+	assertEqual(t, request.CVV, "789")
+	assertEqual(t, request.Amount, MustMoney("1052.50", "NGN"))
+
+	payload, err := requestPayload(request, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, payload["cardno"], "5438898014560229")
+	assertEqual(t, payload["bvn"], "12345678901")
+
+	// a missing amount stays unset instead of being sent as "0.00"
+	capture := CaptureRequest{}
+	if err := mapToRequest(map[string]interface{}{"flwRef": "FLW-1"}, &capture, &capture.Amount); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, capture.Amount, Money{})
+
+	err = mapToRequest(map[string]interface{}{"amount": "abc"}, &capture, &capture.Amount)
+	if !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("Expected ErrInvalidAmount got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+/*
+This file contains the rules used to verify transactions.
+
+Every rule is run on the verified transaction and all the failures are
+reported together in a VerificationError. The default rules implement the
+"Five-Step" verification outlined in
+https://flutterwavedevelopers.readme.io/v1.0/reference#verification,
+custom rules can be added with WithVerificationRules.
+*/
+
+package rave
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Verification : A transaction being verified, it's passed to every VerificationRule
+type Verification struct {
+	// Parameters sent to the verify or xrequery endpoint ("flw_ref", "amount", "currency" ...)
+	Request map[string]interface{}
+
+	// Decoded and raw response
+	Response *VerifyResponse
+	Body     []byte
+}
+
+// VerificationRule : A check that must pass for a transaction to be verified
+type VerificationRule struct {
+	Name  string
+	Check func(v *Verification) error
+}
+
+// VerificationFailure : A rule that failed and the reason it failed
+type VerificationFailure struct {
+	Rule string
+	Err  error
+}
+
+// VerificationError : Returned when a transaction fails one or more verification rules
+type VerificationError struct {
+	Failures []VerificationFailure
+}
+
+func (e *VerificationError) Error() string {
+	reasons := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		reasons[i] = failure.Rule + ": " + failure.Err.Error()
+	}
+
+	return "Transaction not verified: " + strings.Join(reasons, "; ")
+}
+
+// Failed : Reports whether the rule with the given name failed
+func (e *VerificationError) Failed(rule string) bool {
+	for _, failure := range e.Failures {
+		if failure.Rule == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Names of the default verification rules
+const (
+	RuleTransactionReference = "transaction reference"
+	RuleSuccessMessage       = "success message"
+	RuleChargeResponse       = "charge response"
+	RuleCurrency             = "currency"
+	RuleChargedAmount        = "charged amount"
+)
+
+// DefaultVerificationRules : Rules every transaction is verified with
+var DefaultVerificationRules = []VerificationRule{
+	{Name: RuleTransactionReference, Check: verifyTransactionReference},
+	{Name: RuleSuccessMessage, Check: verifySuccessMessage},
+	{Name: RuleChargeResponse, Check: verifyChargeResponse},
+	{Name: RuleCurrency, Check: verifyCurrencyCode},
+	{Name: RuleChargedAmount, Check: verifyChargedAmount},
+}
+
+// WithVerificationRules : Verify transactions with custom rules in addition to DefaultVerificationRules
+func WithVerificationRules(rules ...VerificationRule) Option {
+	return func(r *Rave) {
+		r.verificationRules = append(r.verificationRules[:len(r.verificationRules):len(r.verificationRules)], rules...)
+	}
+}
+
+// getVerificationRules : Returns the default rules followed by the custom ones
+func (r Rave) getVerificationRules() []VerificationRule {
+	return append(DefaultVerificationRules[:len(DefaultVerificationRules):len(DefaultVerificationRules)], r.verificationRules...)
+}
+
+// verifyTransaction : Run every verification rule on the response and report all the failures
+func verifyTransaction(rules []VerificationRule, transactionData map[string]interface{}, response []byte) error {
+	body := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	err := json.Unmarshal(response, &body)
+	if err != nil || len(body.Data) == 0 || body.Data[0] != '{' {
+		return errors.New("Transaction not verified because the response doesn't contain the transaction details")
+	}
+
+	verifyResponse := &VerifyResponse{}
+	err = decodeResponse(response, verifyResponse)
+	if err != nil {
+		return err
+	}
+
+	verification := &Verification{Request: transactionData, Response: verifyResponse, Body: response}
+
+	verificationError := &VerificationError{}
+	for _, rule := range rules {
+		if err := rule.Check(verification); err != nil {
+			verificationError.Failures = append(verificationError.Failures, VerificationFailure{Rule: rule.Name, Err: err})
+		}
+	}
+
+	if len(verificationError.Failures) > 0 {
+		return verificationError
+	}
+
+	return nil
+}
+
+// The Transaction reference should match
+func verifyTransactionReference(v *Verification) error {
+	expected := fmt.Sprint(v.Request["flw_ref"])
+	if v.Response.Data.FlwRef != expected {
+		return fmt.Errorf("the transaction reference doesn't match: '%s' != '%s'", v.Response.Data.FlwRef, expected)
+	}
+
+	return nil
+}
+
+// The success message should equal "Tx Fetched" for a succesful transaction
+func verifySuccessMessage(v *Verification) error {
+	if v.Response.Message != "Tx Fetched" {
+		return fmt.Errorf("the success message '%s' is not equal to 'Tx Fetched'", v.Response.Message)
+	}
+
+	return nil
+}
+
+// The Charge response should equal "00" or "0"
+func verifyChargeResponse(v *Verification) error {
+	chargeResponse := v.Response.Data.ChargeCode
+	if chargeResponse != "00" && chargeResponse != "0" {
+		return fmt.Errorf("the charge response '%s' is not equal to '00' or '0'", chargeResponse)
+	}
+
+	return nil
+}
+
+// The Currency code must match
+func verifyCurrencyCode(v *Verification) error {
+	expected := fmt.Sprint(v.Request["currency"])
+	if !strings.EqualFold(v.Response.Data.Currency, expected) {
+		return fmt.Errorf("the currency code doesn't match: '%s' != '%s'", v.Response.Data.Currency, expected)
+	}
+
+	return nil
+}
+
+// The Charged Amount must be greater than or equal to the amount to be paid
+func verifyChargedAmount(v *Verification) error {
+	expected, err := moneyFromValue(v.Request["amount"], fmt.Sprint(v.Request["currency"]))
+	if err != nil {
+		return err
+	}
+
+	// mismatched currencies are reported by the currency rule, only the values are compared here
+	chargedAmount := v.Response.Data.ChargedAmount
+	if chargedAmount.rat().Cmp(expected.rat()) < 0 {
+		return fmt.Errorf("the charged amount %s is less than the amount to be paid %s", chargedAmount, expected)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+// Tests for the transaction verification rules
+
+package rave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// verifyBody : Returns a verify response with the given details
+func verifyBody(message, flwRef, chargeCode, currency string, chargedAmount interface{}) []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"status": "success", "message": message,
+		"data": map[string]interface{}{
+			"flw_ref": flwRef, "tx_ref": "MXX-AYT-4578", "transaction_currency": currency,
+			"charged_amount": chargedAmount, "amount": chargedAmount, "status": "successful",
+			"flwMeta":  map[string]interface{}{"chargeResponse": chargeCode},
+			"customer": map[string]interface{}{"email": "user@example.com"},
+		},
+	})
+
+	return body
+}
+
+// Every failed rule should be reported, not only the last one
+func TestVerificationReportsEveryFailure(t *testing.T) {
+	t.Parallel()
+
+	request := map[string]interface{}{"flw_ref": "FLW-MOCK-1", "amount": "300", "currency": "NGN"}
+	response := verifyBody("Tx not found", "FLW-MOCK-2", "RR", "USD", "300")
+
+	err := verifyTransaction(DefaultVerificationRules, request, response)
+
+	verificationError := &VerificationError{}
+	if !errors.As(err, &verificationError) {
+		t.Fatalf("Expected a VerificationError got %v", err)
+	}
+
+	assertEqual(t, len(verificationError.Failures), 4)
+	for _, rule := range []string{RuleTransactionReference, RuleSuccessMessage, RuleChargeResponse, RuleCurrency} {
+		if !verificationError.Failed(rule) {
+			t.Errorf("Expected the '%s' rule to fail: %s", rule, err)
+		}
+	}
+
+	if verificationError.Failed(RuleChargedAmount) {
+		t.Errorf("Expected the '%s' rule to pass: %s", RuleChargedAmount, err)
+	}
+}
+
+// Amounts should be compared as decimals whatever their type
+func TestVerificationChargedAmount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount        interface{}
+		chargedAmount interface{}
+		verified      bool
+	}{
+		{"1052.50", "1052.50", true},
+		{"1052.50", 1052.5, true},
+		{"1052.50", "1052.49", false},
+		{"1052.50", 1053, true},
+		{1052.5, json.Number("1052.50"), true},
+		{json.Number("1052.51"), "1052.50", false},
+		{300, "300.00", true},
+		{int64(3000000000), "2999999999.99", false},
+		{"1000", "300", false},
+		{"abc", "300", false},
+		{[]string{"300"}, "300", false},
+	}
+
+	for _, test := range tests {
+		request := map[string]interface{}{"flw_ref": "FLW-MOCK", "amount": test.amount, "currency": "NGN"}
+		response := verifyBody("Tx Fetched", "FLW-MOCK", "00", "NGN", test.chargedAmount)
+
+		err := verifyTransaction(DefaultVerificationRules, request, response)
+		if verified := err == nil; verified != test.verified {
+			t.Errorf("Amount %v charged %v: expected verified=%v got %v", test.amount, test.chargedAmount, test.verified, err)
+		}
+	}
+}
+
+// Custom rules should run after the default ones
+func TestCustomVerificationRules(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write(verifyBody("Tx Fetched", "FLW-MOCK", "00", "NGN", "300"))
+	}))
+	defer server.Close()
+
+	expectEmail := func(email string) VerificationRule {
+		return VerificationRule{Name: "customer email", Check: func(v *Verification) error {
+			if v.Response.Data.CustomerEmail != email {
+				return fmt.Errorf("expected '%s' got '%s'", email, v.Response.Data.CustomerEmail)
+			}
+
+			return nil
+		}}
+	}
+
+	for email, verified := range map[string]bool{"user@example.com": true, "someone@example.com": false} {
+		client := NewClient(
+			WithKeys("FLWPUBK-public-X", "FLWSECK-secret-key-X"),
+			WithBaseURLs("", server.URL),
+			WithVerificationRules(expectEmail(email)),
+		)
+
+		_, err := client.VerifyTransactionTyped(context.Background(), VerifyRequest{
+			FlwRef: "FLW-MOCK", Amount: MustMoney("300", "NGN"), Currency: "NGN",
+		})
+
+		verificationError := &VerificationError{}
+		if verified && err != nil {
+			t.Errorf("Expected %s to be verified got %v", email, err)
+		} else if !verified && (!errors.As(err, &verificationError) || !verificationError.Failed("customer email")) {
+			t.Errorf("Expected the customer email rule to fail for %s got %v", email, err)
+		}
+	}
+}
+
+// A response without a data object should never be verified
+func TestVerificationWithoutData(t *testing.T) {
+	t.Parallel()
+
+	request := map[string]interface{}{"flw_ref": "FLW-MOCK", "amount": "300", "currency": "NGN"}
+	for _, response := range []string{`{"status": "success", "message": "Tx Fetched"}`, `{"data": "Tx Fetched"}`} {
+		if err := verifyTransaction(DefaultVerificationRules, request, []byte(response)); err == nil {
+			t.Errorf("Expected %s not to be verified", response)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+/*
+This file contains the handler for the webhooks Rave sends when a charge,
+transfer or refund completes asynchronously.
+
+Rave sends the secret hash configured on the dashboard in the "verif-hash"
+header of every webhook, requests without the right hash are rejected
+before their body is decoded.
+*/
+
+package rave
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// WebhookHashHeader : Header that contains the secret hash
+const WebhookHashHeader = "verif-hash"
+
+// maxWebhookSize : Webhook payloads larger than this are rejected
+const maxWebhookSize = 1 << 20
+
+// Types of webhook events
+const (
+	EventChargeCompleted = "charge.completed"
+	EventTransfer        = "transfer"
+	EventRefund          = "refund"
+)
+
+// ErrInvalidWebhookHash : The "verif-hash" header doesn't match the secret hash
+var ErrInvalidWebhookHash = errors.New("The webhook hash doesn't match the secret hash")
+
+// WebhookCustomer : Customer of a charge webhook
+type WebhookCustomer struct {
+	ID       int    `json:"id"`
+	Phone    string `json:"phone"`
+	FullName string `json:"fullName"`
+	Email    string `json:"email"`
+}
+
+// WebhookEntity : Card or account that was charged
+type WebhookEntity struct {
+	Card6         string `json:"card6"`
+	CardLast4     string `json:"card_last4"`
+	AccountNumber string `json:"account_number"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+}
+
+// ChargeEvent : A card, account, mobile money ... charge that completed
+type ChargeEvent struct {
+	ID            int             `json:"id"`
+	TxRef         string          `json:"txRef"`
+	FlwRef        string          `json:"flwRef"`
+	OrderRef      string          `json:"orderRef"`
+	CreatedAt     string          `json:"createdAt"`
+	Amount        Money           `json:"amount"`
+	ChargedAmount Money           `json:"charged_amount"`
+	Status        string          `json:"status"`
+	IP            string          `json:"IP"`
+	Currency      string          `json:"currency"`
+	Customer      WebhookCustomer `json:"customer"`
+	Entity        WebhookEntity   `json:"entity"`
+
+	// "event.type" sent by Rave (CARD_TRANSACTION, ACCOUNT_TRANSACTION ...)
+	EventType string `json:"event.type"`
+
+	// Set when the handler re-verifies successful charges
+	Verification *VerifyResponse `json:"-"`
+}
+
+// UnmarshalJSON : Decode the amounts in the currency of the charge
+func (e *ChargeEvent) UnmarshalJSON(body []byte) error {
+	type chargeEvent ChargeEvent
+
+	currency, err := currencyOf(body)
+	if err != nil {
+		return err
+	}
+
+	event := chargeEvent{Amount: Money{Currency: currency}, ChargedAmount: Money{Currency: currency}}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+
+	*e = ChargeEvent(event)
+
+	return nil
+}
+
+// TransferEvent : A transfer that completed or failed
+type TransferEvent struct {
+	ID              int    `json:"id"`
+	AccountNumber   string `json:"account_number"`
+	BankCode        string `json:"bank_code"`
+	BankName        string `json:"bank_name"`
+	FullName        string `json:"fullname"`
+	DateCreated     string `json:"date_created"`
+	Currency        string `json:"currency"`
+	Amount          Money  `json:"amount"`
+	Fee             Money  `json:"fee"`
+	Status          string `json:"status"`
+	Reference       string `json:"reference"`
+	Narration       string `json:"narration"`
+	CompleteMessage string `json:"complete_message"`
+}
+
+// UnmarshalJSON : Decode the amounts in the currency of the transfer
+func (e *TransferEvent) UnmarshalJSON(body []byte) error {
+	type transferEvent TransferEvent
+
+	currency, err := currencyOf(body)
+	if err != nil {
+		return err
+	}
+
+	event := transferEvent{Amount: Money{Currency: currency}, Fee: Money{Currency: currency}}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+
+	*e = TransferEvent(event)
+
+	return nil
+}
+
+// RefundEvent : A refund that completed
+type RefundEvent struct {
+	ID             int    `json:"id"`
+	TransactionID  int    `json:"TransactionId"`
+	FlwRef         string `json:"FlwRef"`
+	AmountRefunded Money  `json:"AmountRefunded"`
+	Currency       string `json:"currency"`
+	Status         string `json:"status"`
+	Destination    string `json:"destination"`
+	CreatedAt      string `json:"createdAt"`
+}
+
+// UnmarshalJSON : Decode the amount in the currency of the refund
+func (e *RefundEvent) UnmarshalJSON(body []byte) error {
+	type refundEvent RefundEvent
+
+	currency, err := currencyOf(body)
+	if err != nil {
+		return err
+	}
+
+	event := refundEvent{AmountRefunded: Money{Currency: currency}}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+
+	*e = RefundEvent(event)
+
+	return nil
+}
+
+// WebhookEvent : A decoded webhook, only the field that matches Type is set
+type WebhookEvent struct {
+	Type     string
+	Charge   *ChargeEvent
+	Transfer *TransferEvent
+	Refund   *RefundEvent
+
+	// Raw payload
+	Body []byte
+}
+
+// ParseWebhook : Decode a webhook payload into a typed event
+func ParseWebhook(body []byte) (*WebhookEvent, error) {
+	payload := struct {
+		EventType string          `json:"event.type"`
+		Transfer  json.RawMessage `json:"transfer"`
+	}{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("Invalid webhook payload: %s", err)
+	}
+
+	event := &WebhookEvent{Body: body}
+	eventType := strings.ToUpper(payload.EventType)
+
+	var err error
+	switch {
+	case eventType == "TRANSFER" || len(payload.Transfer) > 0:
+		event.Type = EventTransfer
+		event.Transfer = &TransferEvent{}
+		err = json.Unmarshal(payload.Transfer, event.Transfer)
+
+	case strings.Contains(eventType, "REFUND"):
+		event.Type = EventRefund
+		event.Refund = &RefundEvent{}
+		err = json.Unmarshal(body, event.Refund)
+
+	case strings.HasSuffix(eventType, "_TRANSACTION"):
+		event.Type = EventChargeCompleted
+		event.Charge = &ChargeEvent{}
+		err = json.Unmarshal(body, event.Charge)
+
+	default:
+		return nil, fmt.Errorf("Unknown webhook event type \"%s\"", payload.EventType)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s webhook: %s", event.Type, err)
+	}
+
+	return event, nil
+}
+
+// WebhookHandler : http.Handler that receives Rave webhooks and dispatches them
+// to the registered callbacks. Callbacks must be registered before the
+// handler starts serving requests.
+//
+// The handler responds with 401 if the hash is wrong, 400 if the payload
+// can't be decoded, 422 if a charge fails verification and 500 if a
+// verification request or a callback fails so Rave sends the webhook again.
+type WebhookHandler struct {
+	secretHash string
+	client     *Rave
+	onError    func(*http.Request, error)
+
+	chargeCallbacks   []func(context.Context, *ChargeEvent) error
+	transferCallbacks []func(context.Context, *TransferEvent) error
+	refundCallbacks   []func(context.Context, *RefundEvent) error
+}
+
+// WebhookOption : Configures a WebhookHandler created with NewWebhookHandler
+type WebhookOption func(*WebhookHandler)
+
+// WithChargeVerification : Verify successful charges with VerifyTransaction before dispatching them
+func WithChargeVerification(client Rave) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.client = &client
+	}
+}
+
+// WithWebhookErrorHandler : Called with every webhook that's rejected or that a callback fails to handle
+func WithWebhookErrorHandler(onError func(*http.Request, error)) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.onError = onError
+	}
+}
+
+// NewWebhookHandler : Constructor for a WebhookHandler that accepts webhooks sent with secretHash
+func NewWebhookHandler(secretHash string, opts ...WebhookOption) *WebhookHandler {
+	handler := &WebhookHandler{secretHash: secretHash}
+	for _, opt := range opts {
+		opt(handler)
+	}
+
+	return handler
+}
+
+// OnChargeCompleted : Register a callback for completed charges
+func (h *WebhookHandler) OnChargeCompleted(callback func(context.Context, *ChargeEvent) error) {
+	h.chargeCallbacks = append(h.chargeCallbacks, callback)
+}
+
+// OnTransfer : Register a callback for transfers
+func (h *WebhookHandler) OnTransfer(callback func(context.Context, *TransferEvent) error) {
+	h.transferCallbacks = append(h.transferCallbacks, callback)
+}
+
+// OnRefund : Register a callback for refunds
+func (h *WebhookHandler) OnRefund(callback func(context.Context, *RefundEvent) error) {
+	h.refundCallbacks = append(h.refundCallbacks, callback)
+}
+
+// ServeHTTP : Check the hash, decode the webhook and dispatch it
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.validHash(req.Header.Get(WebhookHashHeader)) {
+		h.reject(w, req, http.StatusUnauthorized, ErrInvalidWebhookHash)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookSize))
+	if err != nil {
+		h.reject(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	event, err := ParseWebhook(body)
+	if err != nil {
+		h.reject(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.dispatch(req.Context(), event)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if verificationError := (&VerificationError{}); errors.As(err, &verificationError) {
+			status = http.StatusUnprocessableEntity
+		}
+
+		h.reject(w, req, status, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// validHash : Compare the hashes in constant time, an empty secret hash never matches
+func (h *WebhookHandler) validHash(hash string) bool {
+	if h.secretHash == "" {
+		return false
+	}
+
+	// compare digests so the length of the secret hash isn't leaked either
+	expected, actual := sha256.Sum256([]byte(h.secretHash)), sha256.Sum256([]byte(hash))
+
+	return subtle.ConstantTimeCompare(expected[:], actual[:]) == 1
+}
+
+// reject : Report the error and respond with status
+func (h *WebhookHandler) reject(w http.ResponseWriter, req *http.Request, status int, err error) {
+	if h.onError != nil {
+		h.onError(req, err)
+	}
+
+	http.Error(w, http.StatusText(status), status)
+}
+
+// dispatch : Call the callbacks registered for the event
+func (h *WebhookHandler) dispatch(ctx context.Context, event *WebhookEvent) error {
+	switch event.Type {
+	case EventChargeCompleted:
+		if err := h.verifyCharge(ctx, event.Charge); err != nil {
+			return err
+		}
+
+		for _, callback := range h.chargeCallbacks {
+			if err := callback(ctx, event.Charge); err != nil {
+				return err
+			}
+		}
+
+	case EventTransfer:
+		for _, callback := range h.transferCallbacks {
+			if err := callback(ctx, event.Transfer); err != nil {
+				return err
+			}
+		}
+
+	case EventRefund:
+		for _, callback := range h.refundCallbacks {
+			if err := callback(ctx, event.Refund); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyCharge : Re-verify a successful charge if the handler has a client.
+// Failed charges aren't verified because Rave would never confirm them.
+func (h *WebhookHandler) verifyCharge(ctx context.Context, charge *ChargeEvent) error {
+	if h.client == nil || !strings.EqualFold(charge.Status, "successful") {
+		return nil
+	}
+
+	verification, err := h.client.VerifyTransactionTyped(ctx, VerifyRequest{
+		FlwRef: charge.FlwRef, Amount: charge.Amount, Currency: charge.Currency, Normalize: "1",
+	})
+	if err != nil {
+		return err
+	}
+
+	charge.Verification = verification
+
+	return nil
+}
@@ -0,0 +1,194 @@
+// Tests for the webhook handler, using payloads recorded from Rave
+
+package rave
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+const testSecretHash = "my-secret-hash"
+
+// readWebhook : Read a recorded payload from testdata/webhooks
+func readWebhook(t *testing.T, name string) []byte {
+	t.Helper()
+
+	body, err := ioutil.ReadFile(filepath.Join("testdata", "webhooks", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+// sendWebhook : Send a webhook to handler and return the status code
+func sendWebhook(handler http.Handler, method, hash string, body []byte) int {
+	req := httptest.NewRequest(method, "/webhooks/rave", bytes.NewReader(body))
+	if hash != "" {
+		req.Header.Set(WebhookHashHeader, hash)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder.Code
+}
+
+func TestParseWebhook(t *testing.T) {
+	t.Parallel()
+
+	event, err := ParseWebhook(readWebhook(t, "card_transaction.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, event.Type, EventChargeCompleted)
+	assertEqual(t, event.Charge.FlwRef, "FLW-MOCK-72d0d2b27d40b0a2f0a2d46fbfd8aa3a")
+	assertEqual(t, event.Charge.ChargedAmount, MustMoney("1052.50", "NGN"))
+	assertEqual(t, event.Charge.Customer.Email, "salesmock@rave.com")
+	assertEqual(t, event.Charge.Entity.CardLast4, "8381")
+
+	event, err = ParseWebhook(readWebhook(t, "account_transaction.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, event.Type, EventChargeCompleted)
+	assertEqual(t, event.Charge.Amount, MustMoney("300", "UGX"))
+	assertEqual(t, event.Charge.Entity.AccountNumber, "0690000031")
+
+	event, err = ParseWebhook(readWebhook(t, "transfer.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, event.Type, EventTransfer)
+	assertEqual(t, event.Transfer.Reference, "rave-transfer-1528159847684")
+	assertEqual(t, event.Transfer.Fee, MustMoney("45", "NGN"))
+
+	event, err = ParseWebhook(readWebhook(t, "refund.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, event.Type, EventRefund)
+	assertEqual(t, event.Refund.TransactionID, 126122)
+	assertEqual(t, event.Refund.AmountRefunded, MustMoney("1052.50", "NGN"))
+
+	for _, body := range []string{`not json`, `{"event.type": "SOMETHING_ELSE"}`, `{"event.type": "CARD_TRANSACTION", "amount": "abc"}`} {
+		if _, err := ParseWebhook([]byte(body)); err == nil {
+			t.Errorf("Expected an error for %s", body)
+		}
+	}
+}
+
+// Webhooks without the right hash should never reach the callbacks
+func TestWebhookHash(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	var rejected int32
+	handler := NewWebhookHandler(testSecretHash, WithWebhookErrorHandler(func(req *http.Request, err error) {
+		if errors.Is(err, ErrInvalidWebhookHash) {
+			atomic.AddInt32(&rejected, 1)
+		}
+	}))
+	handler.OnChargeCompleted(func(ctx context.Context, event *ChargeEvent) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	body := readWebhook(t, "card_transaction.json")
+	assertEqual(t, sendWebhook(handler, "POST", "", body), http.StatusUnauthorized)
+	assertEqual(t, sendWebhook(handler, "POST", "my-secret-has", body), http.StatusUnauthorized)
+	assertEqual(t, sendWebhook(handler, "POST", testSecretHash+"h", body), http.StatusUnauthorized)
+	assertEqual(t, sendWebhook(handler, "GET", testSecretHash, nil), http.StatusMethodNotAllowed)
+	assertEqual(t, sendWebhook(NewWebhookHandler(""), "POST", "", body), http.StatusUnauthorized)
+	assertEqual(t, atomic.LoadInt32(&calls), int32(0))
+	assertEqual(t, atomic.LoadInt32(&rejected), int32(3))
+
+	assertEqual(t, sendWebhook(handler, "POST", testSecretHash, body), http.StatusOK)
+	assertEqual(t, atomic.LoadInt32(&calls), int32(1))
+}
+
+// Every event should be dispatched to the callbacks registered for it's type
+func TestWebhookDispatch(t *testing.T) {
+	t.Parallel()
+
+	received := map[string]string{}
+	handler := NewWebhookHandler(testSecretHash)
+	handler.OnChargeCompleted(func(ctx context.Context, event *ChargeEvent) error {
+		received[event.EventType] = event.Status
+		return nil
+	})
+	handler.OnTransfer(func(ctx context.Context, event *TransferEvent) error {
+		received[EventTransfer] = event.Status
+		return nil
+	})
+	handler.OnRefund(func(ctx context.Context, event *RefundEvent) error {
+		received[EventRefund] = event.Status
+		return nil
+	})
+
+	for _, name := range []string{"card_transaction.json", "account_transaction.json", "transfer.json", "refund.json"} {
+		assertEqual(t, sendWebhook(handler, "POST", testSecretHash, readWebhook(t, name)), http.StatusOK)
+	}
+
+	assertEqual(t, received["CARD_TRANSACTION"], "successful")
+	assertEqual(t, received["ACCOUNT_TRANSACTION"], "failed")
+	assertEqual(t, received[EventTransfer], "SUCCESSFUL")
+	assertEqual(t, received[EventRefund], "completed")
+
+	// Rave should send the webhook again if a callback fails
+	handler.OnRefund(func(ctx context.Context, event *RefundEvent) error {
+		return errors.New("database is down")
+	})
+	assertEqual(t, sendWebhook(handler, "POST", testSecretHash, readWebhook(t, "refund.json")), http.StatusInternalServerError)
+	assertEqual(t, sendWebhook(handler, "POST", testSecretHash, []byte(`{`)), http.StatusBadRequest)
+}
+
+// Successful charges should be verified before they're dispatched
+func TestWebhookChargeVerification(t *testing.T) {
+	t.Parallel()
+
+	chargedAmount := "1052.50"
+	var verifications int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&verifications, 1)
+		w.Write(verifyBody("Tx Fetched", "FLW-MOCK-72d0d2b27d40b0a2f0a2d46fbfd8aa3a", "00", "NGN", chargedAmount))
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithKeys("FLWPUBK-public-X", "FLWSECK-secret-key-X"),
+		WithBaseURLs("", server.URL),
+		WithRetryPolicy(NoRetries),
+	)
+
+	var verified *VerifyResponse
+	handler := NewWebhookHandler(testSecretHash, WithChargeVerification(client))
+	handler.OnChargeCompleted(func(ctx context.Context, event *ChargeEvent) error {
+		verified = event.Verification
+		return nil
+	})
+
+	assertEqual(t, sendWebhook(handler, "POST", testSecretHash, readWebhook(t, "card_transaction.json")), http.StatusOK)
+	if verified == nil || verified.Data.ChargedAmount != MustMoney("1052.50", "NGN") {
+		t.Errorf("Expected the verified transaction to be dispatched got %+v", verified)
+	}
+
+	// failed charges aren't verified
+	verified = nil
+	assertEqual(t, sendWebhook(handler, "POST", testSecretHash, readWebhook(t, "account_transaction.json")), http.StatusOK)
+	assertEqual(t, atomic.LoadInt32(&verifications), int32(1))
+
+	// Rave charged less than the webhook claims
+	chargedAmount = "1052.49"
+	assertEqual(t, sendWebhook(handler, "POST", testSecretHash, readWebhook(t, "card_transaction.json")), http.StatusUnprocessableEntity)
+	if verified != nil {
+		t.Error("Charges that fail verification shouldn't be dispatched")
+	}
+}